@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// Access classes of methods.
+const (
+	// AccessRead methods only read chain or node state.
+	AccessRead = "read"
+	// AccessWrite methods send or sign transactions or change node configuration.
+	AccessWrite = "write"
+	// AccessStateful methods keep state on the server or node between calls, e.g. filters.
+	AccessStateful = "stateful"
+)
+
+// codeMethodNotSupported is the EIP-1474 error code of methods that the server does not serve.
+const codeMethodNotSupported jsonrpc.ErrorCode = -32004
+
+// writeMethods send or sign transactions or change accounts of the node.
+var writeMethods = map[string]bool{
+	"eth_sendTransaction":      true,
+	"eth_sendRawTransaction":   true,
+	"eth_sign":                 true,
+	"eth_signTransaction":      true,
+	"personal_newAccount":      true,
+	"personal_importRawKey":    true,
+	"personal_unlockAccount":   true,
+	"personal_lockAccount":     true,
+	"personal_sendTransaction": true,
+	"personal_sign":            true,
+}
+
+// writeNamespaces change node configuration with every method.
+var writeNamespaces = map[string]bool{"miner": true}
+
+// statefulMethods keep filters or subscriptions between calls.
+var statefulMethods = map[string]bool{
+	"eth_newFilter":                   true,
+	"eth_newBlockFilter":              true,
+	"eth_newPendingTransactionFilter": true,
+	"eth_uninstallFilter":             true,
+	"eth_getFilterChanges":            true,
+	"eth_getFilterLogs":               true,
+	"eth_subscribe":                   true,
+	"eth_unsubscribe":                 true,
+}
+
+// writeLikeMethod matches names of methods that look like they change state, catalogs declare access of such methods.
+var writeLikeMethod = regexp.MustCompile(
+	`^((admin|clique|miner|personal)_|[a-z0-9]+_(send|sign|submit|import|new|unlock|lock|install|uninstall|subscribe|unsubscribe|set|start|stop|add|remove|create|delete))`)
+
+// accessClasses lists valid access classes.
+var accessClasses = map[string]bool{AccessRead: true, AccessWrite: true, AccessStateful: true}
+
+// AccessClasses maps method names to access classes declared in catalogs.
+type AccessClasses map[string]string
+
+// Of returns access class of a method, methods that are not listed are classified by name.
+func (a AccessClasses) Of(method string) string {
+	if access, ok := a[method]; ok {
+		return access
+	}
+
+	return methodAccess(method)
+}
+
+// methodAccess classifies method as read, write or stateful by name.
+func methodAccess(method string) string {
+	switch {
+	case writeMethods[method] || writeNamespaces[namespace(method)]:
+		return AccessWrite
+	case statefulMethods[method]:
+		return AccessStateful
+	default:
+		return AccessRead
+	}
+}
+
+// ReadOnly rejects write methods and passes other calls to Next.
+type ReadOnly struct {
+	Next   Caller
+	Access AccessClasses
+}
+
+// Call rejects write methods.
+func (r ReadOnly) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	if r.Access.Of(method) == AccessWrite {
+		return nil, UpstreamError{
+			Code:    codeMethodNotSupported,
+			Message: fmt.Sprintf("%s changes state and is not available in read-only mode", method),
+		}
+	}
+
+	return r.Next.Call(ctx, method, params)
+}
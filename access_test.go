@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	var called []string
+
+	backend := callerFunc(func(_ context.Context, method string, _ json.RawMessage) (json.RawMessage, error) {
+		called = append(called, method)
+
+		return json.RawMessage(`"0x1"`), nil
+	})
+
+	h := newTestRPC(t, ReadOnly{Next: backend})
+
+	tx := `{"from":"0x0000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000001"}`
+
+	for _, tc := range []struct {
+		name, body, want string
+	}{
+		{
+			name: "write",
+			body: `{"jsonrpc":"2.0","method":"eth_sendRawTransaction","params":["0x00"],"id":1}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32004,` +
+				`"message":"eth_sendRawTransaction changes state and is not available in read-only mode"},"id":1}`,
+		},
+		{
+			name: "write in batch",
+			body: `[{"jsonrpc":"2.0","method":"eth_sendTransaction","params":[` + tx + `],"id":1},` +
+				`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":2}]`,
+			want: `[{"jsonrpc":"2.0","error":{"code":-32004,` +
+				`"message":"eth_sendTransaction changes state and is not available in read-only mode"},"id":1},` +
+				`{"jsonrpc":"2.0","result":"0x1","id":2}]`,
+		},
+		{
+			name: "stateful",
+			body: `{"jsonrpc":"2.0","method":"eth_newBlockFilter","params":[],"id":1}`,
+			want: `{"jsonrpc":"2.0","result":"0x1","id":1}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := postRPC(t, h, tc.body); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if len(called) != 2 || called[0] != "eth_blockNumber" || called[1] != "eth_newBlockFilter" {
+		t.Errorf("write methods reached the backend: %v", called)
+	}
+
+	// Access declared in catalog takes precedence over classification by name.
+	ro := ReadOnly{Next: backend, Access: AccessClasses{"cronos_sendBundle": AccessWrite, "eth_sign": AccessRead}}
+
+	_, err := ro.Call(context.Background(), "cronos_sendBundle", json.RawMessage(`[]`))
+
+	var upstreamErr UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.Code != codeMethodNotSupported {
+		t.Errorf("cronos_sendBundle: unexpected error %v", err)
+	}
+
+	if _, err := ro.Call(context.Background(), "eth_sign", json.RawMessage(`[]`)); err != nil {
+		t.Errorf("eth_sign: unexpected error %v", err)
+	}
+}
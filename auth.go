@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/swaggest/jsonrpc"
+	"github.com/swaggest/openapi-go/openapi3"
+)
+
+// API key is passed with request header or, e.g. by WebSocket clients that can not set headers, query parameter.
+const (
+	APIKeyHeader = "X-API-Key"
+	APIKeyParam  = "api_key"
+)
+
+// apiKeyScheme is a name of OpenAPI security scheme of API keys.
+const apiKeyScheme = "apiKey"
+
+// apiKeyRequests counts authorized requests per key label, published by expvar handler.
+var apiKeyRequests = expvar.NewMap("api_key_requests")
+
+// APIKey grants access to JSON-RPC endpoints, its label names the key owner in metrics.
+type APIKey struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+
+	// Methods allow or deny methods to the key in addition to network rules.
+	Methods MethodRules `json:"methods,omitempty"`
+}
+
+// APIKeys is a list of keys that are allowed to call methods.
+type APIKeys struct {
+	List []*APIKey `json:"keys"`
+
+	// byHash finds keys by SHA-256 hash, so that lookup time tells nothing about stored keys.
+	byHash map[[sha256.Size]byte]*APIKey
+}
+
+// LoadAPIKeys reads keys from a YAML or JSON file.
+func LoadAPIKeys(fileName string) (*APIKeys, error) {
+	data, err := ioutil.ReadFile(fileName) // nolint:gosec // File name comes from trusted configuration.
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := yamlToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", fileName, err)
+	}
+
+	var k APIKeys
+
+	if err := json.Unmarshal(j, &k); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", fileName, err)
+	}
+
+	if len(k.List) == 0 {
+		return nil, fmt.Errorf("no API keys in %s", fileName)
+	}
+
+	k.byHash = make(map[[sha256.Size]byte]*APIKey, len(k.List))
+
+	for i, key := range k.List {
+		if key.Key == "" || key.Label == "" {
+			return nil, fmt.Errorf("API key %d: key and label are required", i+1)
+		}
+
+		hash := sha256.Sum256([]byte(key.Key))
+
+		if _, exists := k.byHash[hash]; exists {
+			return nil, fmt.Errorf("API key %s: duplicate key", key.Label)
+		}
+
+		if err := key.Methods.Validate(); err != nil {
+			return nil, fmt.Errorf("API key %s: %w", key.Label, err)
+		}
+
+		k.byHash[hash] = key
+	}
+
+	return &k, nil
+}
+
+// Find returns key by its value.
+func (k *APIKeys) Find(key string) (*APIKey, bool) {
+	found, ok := k.byHash[sha256.Sum256([]byte(key))]
+
+	return found, ok
+}
+
+type apiKeyCtxKey struct{}
+
+// APIKeyFromContext returns API key of the request, nil if request has none.
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyCtxKey{}).(*APIKey)
+
+	return key
+}
+
+// Middleware puts API key passed with header or query parameter into request context,
+// requests with unknown keys are rejected, as well as requests without key if it is required.
+// Nil keys let every request through.
+func (k *APIKeys) Middleware(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if k == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(APIKeyHeader)
+			if value == "" {
+				value = r.URL.Query().Get(APIKeyParam)
+			}
+
+			if value == "" {
+				if required {
+					writeErrorStatus(w, http.StatusUnauthorized, jsonrpc.CodeInvalidRequest,
+						errors.New("API key is required in "+APIKeyHeader+" header or "+APIKeyParam+" query parameter"))
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			key, found := k.Find(value)
+			if !found {
+				writeErrorStatus(w, http.StatusUnauthorized, jsonrpc.CodeInvalidRequest, errors.New("invalid API key"))
+
+				return
+			}
+
+			if required {
+				apiKeyRequests.Add(key.Label, 1)
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyCtxKey{}, key)))
+		})
+	}
+}
+
+// PageMiddleware requires API key for Swagger UI page served at basePath. Static assets
+// embedded in the binary are served at other paths and stay public, as they are the same
+// for every server.
+func (k *APIKeys) PageMiddleware(basePath string, embedded bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if k == nil {
+			return next
+		}
+
+		page := k.Middleware(true)(next)
+
+		if !embedded {
+			return page
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.TrimSuffix(r.URL.Path, "/") == strings.TrimSuffix(basePath, "/") {
+				page.ServeHTTP(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// keyAllows tells whether API key of the request, if any, allows the method.
+func keyAllows(ctx context.Context, method string) bool {
+	key := APIKeyFromContext(ctx)
+
+	return key == nil || key.Methods.Allowed(method)
+}
+
+// setupAPIKeySecurity documents API key security scheme, Swagger UI shows Authorize button for it.
+func setupAPIKeySecurity(spec *openapi3.Spec) {
+	description := "API key, can also be passed with " + APIKeyParam + " query parameter."
+
+	spec.ComponentsEns().SecuritySchemesEns().WithMapOfSecuritySchemeOrRefValuesItem(apiKeyScheme,
+		openapi3.SecuritySchemeOrRef{
+			SecurityScheme: &openapi3.SecurityScheme{
+				APIKeySecurityScheme: &openapi3.APIKeySecurityScheme{
+					Name:        APIKeyHeader,
+					In:          openapi3.APIKeySecuritySchemeInHeader,
+					Description: &description,
+				},
+			},
+		})
+
+	spec.WithSecurity(map[string][]string{apiKeyScheme: {}})
+}
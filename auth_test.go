@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/swaggest/jsonrpc"
+)
+
+// loadTestKeys loads keys of a team that calls any method and of a partner that calls eth_ methods.
+func loadTestKeys(t *testing.T) *APIKeys {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "keys.yaml")
+	keys := "keys:\n" +
+		"  - {key: team-key, label: team}\n" +
+		"  - {key: partner-key, label: partner, methods: {allow: [eth_*]}}\n"
+
+	if err := ioutil.WriteFile(fileName, []byte(keys), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := LoadAPIKeys(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := loadTestKeys(t)
+
+	backend := callerFunc(func(context.Context, string, json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`"0x1"`), nil
+	})
+
+	networks := &Networks{List: []*Network{
+		{Name: "mainnet", caller: backend},
+		{Name: "public", caller: backend, Namespaces: map[string]bool{"web3": false}},
+	}}
+
+	rpc := newTestRPC(t, networks)
+	rpc.Handler = Passthrough{Handler: rpc.Handler.(Passthrough).Handler, Allowed: networks.Allows}
+
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("page"))
+	})
+
+	// Routes are protected the way main does.
+	r := chi.NewRouter()
+	r.Mount("/rpc", keys.Middleware(true)(networks.Middleware(rpc)))
+	r.Method(http.MethodGet, "/openrpc.json", keys.Middleware(true)(networks.DocHandler(func() ([]byte, error) {
+		return []byte(`{"methods":[{"name":"eth_chainId"},{"name":"net_version"},{"name":"web3_clientVersion"}]}`), nil
+	}, filterOpenRPC)))
+	r.Mount("/docs/swagger", keys.PageMiddleware("/docs/swagger", true)(page))
+	r.Mount("/docs/cdn", keys.PageMiddleware("/docs/cdn", false)(page))
+
+	const (
+		netVersion    = `{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`
+		clientVersion = `{"jsonrpc":"2.0","method":"web3_clientVersion","params":[],"id":1}`
+		notFound      = `"code":-32601`
+	)
+
+	for _, tc := range []struct {
+		name   string
+		target string
+		header string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			name: "rpc without key", target: "/rpc", body: netVersion,
+			status: http.StatusUnauthorized, want: "API key is required",
+		},
+		{
+			name: "rpc with invalid key", target: "/rpc", header: "nope", body: netVersion,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "rpc with header key", target: "/rpc", header: "team-key", body: netVersion,
+			status: http.StatusOK, want: `"0x1"`,
+		},
+		{
+			name: "rpc with query key", target: "/rpc?api_key=team-key", body: netVersion,
+			status: http.StatusOK, want: `"0x1"`,
+		},
+		{
+			name: "rpc denied to key", target: "/rpc", header: "partner-key", body: netVersion,
+			status: http.StatusOK, want: notFound,
+		},
+		{
+			name: "rpc disabled on network", target: "/rpc?network=public", header: "team-key", body: clientVersion,
+			status: http.StatusOK, want: notFound,
+		},
+		{name: "openrpc without key", target: "/openrpc.json", status: http.StatusUnauthorized},
+		{
+			name: "openrpc of team", target: "/openrpc.json?api_key=team-key", status: http.StatusOK,
+			want: `"eth_chainId"},{"name":"net_version"},{"name":"web3_clientVersion"`,
+		},
+		{
+			name: "openrpc of partner", target: "/openrpc.json?api_key=partner-key",
+			status: http.StatusOK, want: `[{"name":"eth_chainId"}]`,
+		},
+		{
+			name: "openrpc of network", target: "/openrpc.json?network=public&api_key=team-key", status: http.StatusOK,
+			want: `[{"name":"eth_chainId"},{"name":"net_version"}]`,
+		},
+		{name: "page without key", target: "/docs/swagger/", status: http.StatusUnauthorized},
+		{name: "page with key", target: "/docs/swagger/?api_key=team-key", status: http.StatusOK, want: "page"},
+		{name: "embedded asset", target: "/docs/swagger/swagger-ui-bundle.js", status: http.StatusOK, want: "page"},
+		{name: "cdn page without key", target: "/docs/cdn/anything", status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tc.body != "" {
+				method = http.MethodPost
+			}
+
+			req := httptest.NewRequest(method, tc.target, strings.NewReader(tc.body))
+			if tc.header != "" {
+				req.Header.Set(APIKeyHeader, tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status || !strings.Contains(rec.Body.String(), tc.want) {
+				t.Errorf("got %d %s, want %d with %s", rec.Code, rec.Body.String(), tc.status, tc.want)
+			}
+		})
+	}
+}
+
+// TestREADMEErrorCodes keeps error codes documented in README in line with the ones the server responds with,
+// responses themselves are checked by tests of every feature.
+func TestREADMEErrorCodes(t *testing.T) {
+	readme, err := ioutil.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		text string
+		code int
+	}{
+		{text: "Calls of a disabled namespace fail with `%d`", code: int(jsonrpc.CodeMethodNotFound)},
+		{text: "Denied methods fail with `%d`", code: int(jsonrpc.CodeMethodNotFound)},
+		{text: "Calls fail with `%d` as for an unknown method", code: int(jsonrpc.CodeMethodNotFound)},
+		{text: "write methods fail with `%d`", code: int(codeMethodNotSupported)},
+		{text: "`eth_subscribe` calls fail with error `%d`", code: int(codeLimitExceeded)},
+		{text: "rejected with HTTP status %d", code: http.StatusUnauthorized},
+	} {
+		if text := fmt.Sprintf(tc.text, tc.code); !strings.Contains(string(readme), text) {
+			t.Errorf("README does not say %q", text)
+		}
+	}
+}
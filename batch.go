@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/swaggest/jsonrpc"
+	"github.com/swaggest/openapi-go/openapi3"
+)
+
+// BatchOperation is a spec path that documents batch requests, it is not a JSON-RPC method.
+const BatchOperation = "batch"
+
+// BatchHandler serves JSON-RPC 2.0 batch arrays by passing every item to the handler
+// as a single request, other requests are passed as is. Batches of more than Limit
+// items are rejected, zero Limit allows any size.
+type BatchHandler struct {
+	Handler     http.Handler
+	Concurrency int
+	Limit       int
+}
+
+// ServeHTTP executes batch items in parallel and responds with their results in order.
+func (b BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, jsonrpc.CodeParseError, fmt.Errorf("failed to read request body: %w", err))
+
+		return
+	}
+
+	body = bytes.TrimLeft(body, " \t\r\n")
+	if len(body) == 0 || body[0] != '[' {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		b.Handler.ServeHTTP(w, r)
+
+		return
+	}
+
+	var items []json.RawMessage
+
+	if err := json.Unmarshal(body, &items); err != nil {
+		writeError(w, jsonrpc.CodeParseError, fmt.Errorf("failed to unmarshal batch: %w", err))
+
+		return
+	}
+
+	if len(items) == 0 {
+		writeError(w, jsonrpc.CodeInvalidRequest, errors.New("empty batch"))
+
+		return
+	}
+
+	if b.Limit > 0 && len(items) > b.Limit {
+		writeError(w, jsonrpc.CodeInvalidRequest,
+			fmt.Errorf("batch of %d requests exceeds limit of %d", len(items), b.Limit))
+
+		return
+	}
+
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, concurrency)
+		responses = make([]json.RawMessage, len(items))
+	)
+
+	for i, item := range items {
+		item = bytes.TrimSpace(item)
+		if len(item) == 0 || item[0] != '{' {
+			responses[i] = invalidBatchItem()
+
+			continue
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, item []byte) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			req := r.Clone(r.Context())
+			req.Body = ioutil.NopCloser(bytes.NewReader(item))
+			req.ContentLength = int64(len(item))
+
+			resp := &bufferedResponse{header: http.Header{}}
+			b.Handler.ServeHTTP(resp, req)
+
+			// Notifications have no response.
+			if out := bytes.TrimSpace(resp.body.Bytes()); len(out) > 0 {
+				responses[i] = out
+			}
+		}(i, item)
+	}
+
+	wg.Wait()
+
+	result := make([]json.RawMessage, 0, len(responses))
+
+	for _, resp := range responses {
+		if resp != nil {
+			result = append(result, resp)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset: utf-8")
+
+	// Batch of notifications has no response.
+	if len(result) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, jsonrpc.CodeInternalError, err)
+
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+func invalidBatchItem() json.RawMessage {
+	data, err := json.Marshal(jsonrpc.Response{
+		JSONRPC: "2.0",
+		Error: &jsonrpc.Error{
+			Code:    jsonrpc.CodeInvalidRequest,
+			Message: "batch item must be a request object",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+// bufferedResponse collects response of a batch item.
+type bufferedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponse) WriteHeader(int) {}
+
+// setupBatchOperation documents batch requests with an example of given method calls.
+func (c *Catalog) setupBatchOperation(concurrency, limit int) func(op *openapi3.Operation) error {
+	return func(op *openapi3.Operation) error {
+		op.WithID(BatchOperation)
+		op.WithTags("RPC Methods")
+		op.WithSummary("Sends several calls in a single JSON-RPC 2.0 batch request.")
+		op.WithDescription("Request body is an array of JSON-RPC request objects, " +
+			"up to " + strconv.Itoa(concurrency) + " of them are executed in parallel, " +
+			"batches of more than " + strconv.Itoa(limit) + " requests are rejected. " +
+			"Response is an array of response objects in the order of requests, " +
+			"a failed call has an error instead of result and does not affect other calls. " +
+			"Notifications, requests without id, have no response.")
+
+		var (
+			requests  []interface{}
+			responses []interface{}
+		)
+
+		for _, m := range c.Methods {
+			if len(m.Examples) == 0 || len(requests) == 3 {
+				continue
+			}
+
+			e := m.Examples[0]
+			id := len(requests) + 1
+
+			requests = append(requests, map[string]interface{}{
+				"jsonrpc": "2.0", "id": id, "method": m.Name, "params": e.params(),
+			})
+			responses = append(responses, map[string]interface{}{
+				"jsonrpc": "2.0", "id": id, "result": e.Result,
+			})
+		}
+
+		var reqExample, respExample interface{} = requests, responses
+
+		op.RequestBodyEns().RequestBodyEns().WithRequired(true).WithContentItem("application/json", openapi3.MediaType{
+			Schema:  batchSchema("JSON-RPC request.", batchRequestSchema()),
+			Example: &reqExample,
+		})
+
+		resp := openapi3.Response{Description: "Responses in the order of requests."}
+		resp.WithContentItem("application/json", openapi3.MediaType{
+			Schema:  batchSchema("JSON-RPC response.", batchResponseSchema()),
+			Example: &respExample,
+		})
+		op.Responses.WithMapOfResponseOrRefValuesItem(strconv.Itoa(http.StatusOK), openapi3.ResponseOrRef{
+			Response: &resp,
+		})
+
+		return nil
+	}
+}
+
+func batchSchema(description string, item openapi3.Schema) *openapi3.SchemaOrRef {
+	item.WithDescription(description)
+
+	s := (&openapi3.Schema{}).WithType(openapi3.SchemaTypeArray).WithMinItems(1)
+	s.Items = &openapi3.SchemaOrRef{Schema: &item}
+
+	return &openapi3.SchemaOrRef{Schema: s}
+}
+
+func batchRequestSchema() openapi3.Schema {
+	s := openapi3.Schema{}
+	s.WithType(openapi3.SchemaTypeObject)
+	s.WithRequired("jsonrpc", "method")
+	s.WithProperties(map[string]openapi3.SchemaOrRef{
+		"jsonrpc": {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeString).WithEnum("2.0")},
+		"id": {Schema: (&openapi3.Schema{}).
+			WithDescription("Request id, omitted for notifications.")},
+		"method": {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeString)},
+		"params": {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeArray).
+			WithDescription("Positional params.")},
+	})
+
+	return s
+}
+
+func batchResponseSchema() openapi3.Schema {
+	errSchema := openapi3.Schema{}
+	errSchema.WithType(openapi3.SchemaTypeObject)
+	errSchema.WithRequired("code", "message")
+	errSchema.WithProperties(map[string]openapi3.SchemaOrRef{
+		"code":    {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeInteger)},
+		"message": {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeString)},
+		"data":    {Schema: &openapi3.Schema{}},
+	})
+
+	s := openapi3.Schema{}
+	s.WithType(openapi3.SchemaTypeObject)
+	s.WithRequired("jsonrpc", "id")
+	s.WithProperties(map[string]openapi3.SchemaOrRef{
+		"jsonrpc": {Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeString).WithEnum("2.0")},
+		"id":      {Schema: (&openapi3.Schema{}).WithDescription("Id of the request, null if it was not readable.")},
+		"result":  {Schema: (&openapi3.Schema{}).WithDescription("Method result, omitted on error.")},
+		"error":   {Schema: errSchema.WithDescription("Call error, omitted on success.")},
+	})
+
+	return s
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchHandler(t *testing.T) {
+	h := newTestRPC(t, callerFunc(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "net_version":
+			// Slow call finishes after the ones that follow it.
+			time.Sleep(50 * time.Millisecond)
+
+			return json.RawMessage(`"338"`), nil
+		case "eth_chainId":
+			return json.RawMessage(`"0x152"`), nil
+		default:
+			return nil, methodNotFound(method)
+		}
+	}))
+	h.Limit = 3
+
+	slow := `{"jsonrpc":"2.0","method":"net_version","params":[],"id":1}`
+	fast := `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":2}`
+	notification := `{"jsonrpc":"2.0","method":"net_version","params":[]}`
+
+	for _, tc := range []struct {
+		name, body, want string
+	}{
+		{
+			name: "order of requests",
+			body: `[` + slow + `,` + fast + `]`,
+			want: `[{"jsonrpc":"2.0","result":"338","id":1},{"jsonrpc":"2.0","result":"0x152","id":2}]`,
+		},
+		{
+			name: "notifications",
+			body: `[` + notification + `,` + fast + `,` + notification + `]`,
+			want: `[{"jsonrpc":"2.0","result":"0x152","id":2}]`,
+		},
+		{
+			name: "only notifications",
+			body: `[` + notification + `,` + notification + `]`,
+			want: ``,
+		},
+		{
+			name: "invalid item",
+			body: `[1,` + fast + `]`,
+			want: `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"batch item must be a request object"},"id":null},` +
+				`{"jsonrpc":"2.0","result":"0x152","id":2}]`,
+		},
+		{
+			name: "failed item",
+			body: `[{"jsonrpc":"2.0","method":"eth_foo","params":[],"id":3},` + fast + `]`,
+			want: `[{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: eth_foo"},"id":3},` +
+				`{"jsonrpc":"2.0","result":"0x152","id":2}]`,
+		},
+		{
+			name: "single request",
+			body: fast,
+			want: `{"jsonrpc":"2.0","result":"0x152","id":2}`,
+		},
+		{
+			name: "empty batch",
+			body: `[]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"empty batch"},"id":null}`,
+		},
+		{
+			name: "batch over limit",
+			body: `[` + strings.Repeat(fast+`,`, 3) + fast + `]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"batch of 4 requests exceeds limit of 3"},"id":null}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := postRPC(t, h, tc.body); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// CassetteEntry is a recorded call, cassette files have one entry per line.
+type CassetteEntry struct {
+	Network string          `json:"network"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+// Recorder appends calls and their outcomes to a JSONL cassette file.
+type Recorder struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenRecorder opens cassette file for appending, the file is created if it does not exist.
+func OpenRecorder(fileName string) (*Recorder, error) {
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) // nolint:gosec // File name comes from trusted configuration.
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{f: f}, nil
+}
+
+// Caller returns caller that records calls of the network to the cassette.
+func (r *Recorder) Caller(network string, next Caller) Caller {
+	return recordingCaller{recorder: r, network: network, next: next}
+}
+
+func (r *Recorder) write(e CassetteEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.f.Write(append(line, '\n'))
+
+	return err
+}
+
+type recordingCaller struct {
+	recorder *Recorder
+	network  string
+	next     Caller
+}
+
+// Call forwards the call and records its result or JSON-RPC error, transport errors are not recorded.
+//
+// Methods of dev namespaces are not recorded, their params carry passwords and raw keys.
+func (c recordingCaller) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	result, err := c.next.Call(ctx, method, params)
+	if devNamespaces[namespace(method)] {
+		return result, err
+	}
+
+	e := CassetteEntry{Network: c.network, Method: method, Params: params, Result: result}
+
+	var upstreamErr UpstreamError
+
+	switch {
+	case err == nil:
+	case errors.As(err, &upstreamErr):
+		e.Error = &jsonrpc.Error{Code: upstreamErr.Code, Message: upstreamErr.Message, Data: upstreamErr.Data}
+	default:
+		return result, err
+	}
+
+	if len(e.Params) == 0 {
+		e.Params = json.RawMessage("[]")
+	}
+
+	if werr := c.recorder.write(e); werr != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", method, werr)
+	}
+
+	return result, err
+}
+
+// Cassette answers calls with recorded responses.
+//
+// Calls are matched by network, method and params, repeated calls get
+// recorded responses in order, the last one is repeated when exhausted.
+type Cassette struct {
+	mu      sync.Mutex
+	entries map[string][]CassetteEntry
+	played  map[string]int
+}
+
+// LoadCassette reads JSONL cassette file.
+func LoadCassette(fileName string) (*Cassette, error) {
+	f, err := os.Open(fileName) // nolint:gosec // File name comes from trusted configuration.
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	c := &Cassette{entries: map[string][]CassetteEntry{}, played: map[string]int{}}
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64*1024*1024)
+
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+
+		var e CassetteEntry
+
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s:%d: %w", fileName, line, err)
+		}
+
+		key, err := cassetteKey(e.Network, e.Method, e.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s:%d: %w", fileName, line, err)
+		}
+
+		c.entries[key] = append(c.entries[key], e)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", fileName, err)
+	}
+
+	return c, nil
+}
+
+// Caller returns caller that replays recorded calls of the network.
+func (c *Cassette) Caller(network string) Caller {
+	return replayingCaller{cassette: c, network: network}
+}
+
+type replayingCaller struct {
+	cassette *Cassette
+	network  string
+}
+
+// Call returns recorded result or error of a matching call.
+func (c replayingCaller) Call(_ context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	key, err := cassetteKey(c.network, method, params)
+	if err != nil {
+		return nil, invalidParams("invalid params: %v", err)
+	}
+
+	c.cassette.mu.Lock()
+	entries := c.cassette.entries[key]
+	i := c.cassette.played[key]
+
+	if i < len(entries)-1 {
+		c.cassette.played[key]++
+	}
+	c.cassette.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil, UpstreamError{
+			Code:    -32000,
+			Message: fmt.Sprintf("no recorded response for %s with params %s on %s", method, params, c.network),
+		}
+	}
+
+	e := entries[i]
+	if e.Error != nil {
+		return nil, UpstreamError{Code: e.Error.Code, Message: e.Error.Message, Data: e.Error.Data}
+	}
+
+	if e.Result == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	return e.Result, nil
+}
+
+// cassetteKey identifies a call regardless of params formatting and object keys order.
+func cassetteKey(network, method string, params json.RawMessage) (string, error) {
+	var v interface{} = []interface{}{}
+
+	if len(params) > 0 && string(params) != "null" {
+		d := json.NewDecoder(bytes.NewReader(params))
+		d.UseNumber()
+
+		if err := d.Decode(&v); err != nil {
+			return "", err
+		}
+	}
+
+	p, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return network + "\n" + method + "\n" + string(p), nil
+}
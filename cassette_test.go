@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	recorder, err := OpenRecorder(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balances := []string{`"0x1"`, `"0x2"`}
+	backend := callerFunc(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		switch method {
+		case "eth_getBalance":
+			b := balances[0]
+			balances = balances[1:]
+
+			return json.RawMessage(b), nil
+		case "eth_call":
+			return nil, UpstreamError{Code: 3, Message: "execution reverted", Data: "0x08c379a0"}
+		case "personal_unlockAccount":
+			return json.RawMessage("true"), nil
+		default:
+			return nil, errors.New("connection refused")
+		}
+	})
+
+	ctx := context.Background()
+	rec := recorder.Caller("testnet", backend)
+
+	for _, call := range []struct{ method, params string }{
+		{"eth_getBalance", `["0xa","latest"]`},
+		{"eth_getBalance", `["0xa","latest"]`},
+		{"eth_call", `[{"to":"0xb","data":"0x01"},"latest"]`},
+		{"personal_unlockAccount", `["0xa","secret",0]`},
+		{"eth_blockNumber", `[]`},
+	} {
+		_, _ = rec.Call(ctx, call.method, json.RawMessage(call.params))
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("expected 3 recorded calls, got %d:\n%s", n, data)
+	}
+
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("password recorded:\n%s", data)
+	}
+
+	cassette, err := LoadCassette(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := cassette.Caller("testnet")
+
+	// Repeated calls get recorded results in order, the last one is repeated.
+	for _, want := range []string{`"0x1"`, `"0x2"`, `"0x2"`} {
+		res, err := replay.Call(ctx, "eth_getBalance", json.RawMessage(` [ "0xa", "latest" ] `))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(res) != want {
+			t.Errorf("got %s, want %s", res, want)
+		}
+	}
+
+	_, err = replay.Call(ctx, "eth_call", json.RawMessage(`[{"data":"0x01","to":"0xb"},"latest"]`))
+
+	var upstreamErr UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.Code != 3 || upstreamErr.Data != "0x08c379a0" {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	for _, miss := range []struct{ network, method, params string }{
+		{"testnet", "personal_unlockAccount", `["0xa","secret",0]`},
+		{"testnet", "eth_blockNumber", `[]`},
+		{"testnet", "eth_getBalance", `["0xa","earliest"]`},
+		{"mainnet", "eth_getBalance", `["0xa","latest"]`},
+	} {
+		_, err := cassette.Caller(miss.network).Call(ctx, miss.method, json.RawMessage(miss.params))
+		if !errors.As(err, &upstreamErr) || upstreamErr.Code != -32000 ||
+			!strings.HasPrefix(upstreamErr.Message, "no recorded response for "+miss.method) {
+			t.Errorf("%s %s: unexpected error %v", miss.network, miss.method, err)
+		}
+	}
+}
+
+func TestCassetteKey(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{`[{"to":"0xb","data":"0x01"},"latest"]`, `[ {"data":"0x01", "to":"0xb"}, "latest" ]`, true},
+		{``, `[]`, true},
+		{`null`, `[]`, true},
+		{`[12345678901234567891]`, `[12345678901234567890]`, false},
+		{`["0xa","latest"]`, `["latest","0xa"]`, false},
+	} {
+		a, err := cassetteKey("testnet", "eth_call", json.RawMessage(tc.a))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := cassetteKey("testnet", "eth_call", json.RawMessage(tc.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (a == b) != tc.equal {
+			t.Errorf("%s and %s: got keys %q and %q", tc.a, tc.b, a, b)
+		}
+	}
+
+	if _, err := cassetteKey("testnet", "eth_call", json.RawMessage(`[`)); err == nil {
+		t.Error("expected error for malformed params")
+	}
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/swaggest/jsonrpc"
+	"github.com/swaggest/jsonschema-go"
+	"github.com/swaggest/openapi-go/openapi3"
+	"github.com/swaggest/usecase"
+	"gopkg.in/yaml.v2"
+)
+
+// Catalog is a declarative list of JSON-RPC methods.
+type Catalog struct {
+	Info    CatalogInfo                `json:"info"`
+	Types   string                     `json:"types,omitempty"`
+	Schemas map[string]json.RawMessage `json:"schemas,omitempty"`
+	Methods []Method                   `json:"methods"`
+}
+
+// CatalogInfo describes the API.
+type CatalogInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Method describes a JSON-RPC method.
+type Method struct {
+	Name        string              `json:"name"`
+	Tags        []string            `json:"tags,omitempty"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	Params      []ContentDescriptor `json:"params,omitempty"`
+	Result      *ContentDescriptor  `json:"result,omitempty"`
+	Examples    []Example           `json:"examples,omitempty"`
+	// Access is read, write or stateful, methods without it are classified by name.
+	Access string `json:"access,omitempty"`
+}
+
+// ContentDescriptor describes a positional param or a result.
+type ContentDescriptor struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// Example is a sample call of a method.
+type Example struct {
+	Name   string            `json:"name,omitempty"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result,omitempty"`
+}
+
+// Sets of named types that catalog schemas can refer to, chosen with catalog types.
+const (
+	TypesEthereum   = "ethereum"
+	TypesTendermint = "tendermint"
+)
+
+// devNamespaces are namespaces of methods that manage node keys, they are served only in dev mode.
+var devNamespaces = map[string]bool{"personal": true}
+
+// LoadCatalog reads catalog from a YAML or JSON file, OpenRPC documents are imported.
+func LoadCatalog(fileName string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(fileName) // nolint:gosec // File name comes from trusted configuration.
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := parseCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", fileName, err)
+	}
+
+	seen := make(map[string]bool, len(c.Methods))
+
+	for _, m := range c.Methods {
+		if m.Name == "" {
+			return nil, errors.New("method name is required")
+		}
+
+		if seen[m.Name] {
+			return nil, fmt.Errorf("duplicate method %s", m.Name)
+		}
+
+		seen[m.Name] = true
+	}
+
+	if _, err := c.schemaTypes(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	return c, nil
+}
+
+// schemaTypes returns named types of the catalog, Ethereum types by default.
+func (c *Catalog) schemaTypes() ([]interface{}, error) {
+	switch c.Types {
+	case "", TypesEthereum:
+		return schemaTypes, nil
+	case TypesTendermint:
+		return tendermintSchemaTypes, nil
+	default:
+		return nil, fmt.Errorf("unknown types %q, expected %s or %s", c.Types, TypesEthereum, TypesTendermint)
+	}
+}
+
+// parseCatalog decodes catalog or OpenRPC document.
+func parseCatalog(data []byte) (*Catalog, error) {
+	j, err := yamlToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe struct {
+		OpenRPC string `json:"openrpc"`
+	}
+
+	if err := json.Unmarshal(j, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.OpenRPC != "" {
+		return importOpenRPC(j)
+	}
+
+	var c Catalog
+
+	if err := json.Unmarshal(j, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Filter returns a copy of catalog with methods that keep returns true for.
+func (c *Catalog) Filter(keep func(m Method) bool) *Catalog {
+	filtered := *c
+	filtered.Methods = make([]Method, 0, len(c.Methods))
+
+	for _, m := range c.Methods {
+		if keep(m) {
+			filtered.Methods = append(filtered.Methods, m)
+		}
+	}
+
+	return &filtered
+}
+
+// Extend adds methods of an extension catalog, they must belong to namespaces that
+// the catalog does not have. It returns namespaces of the added methods.
+func (c *Catalog) Extend(ext *Catalog) ([]string, error) {
+	if ext.Types != c.Types {
+		return nil, fmt.Errorf("extension types %q differ from catalog types %q", ext.Types, c.Types)
+	}
+
+	own := make(map[string]bool, len(c.Methods))
+	for _, m := range c.Methods {
+		own[namespace(m.Name)] = true
+	}
+
+	added := map[string]bool{}
+
+	var namespaces []string
+
+	for _, m := range ext.Methods {
+		ns := namespace(m.Name)
+
+		if own[ns] {
+			return nil, fmt.Errorf("%s: namespace %s is already in the catalog", m.Name, ns)
+		}
+
+		if !added[ns] {
+			added[ns] = true
+
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	for name := range ext.Schemas {
+		if _, exists := c.Schemas[name]; exists {
+			return nil, fmt.Errorf("duplicate schema %s", name)
+		}
+	}
+
+	// Catalog is only changed when whole extension is valid.
+	c.Methods = append(c.Methods, ext.Methods...)
+
+	if c.Schemas == nil && len(ext.Schemas) > 0 {
+		c.Schemas = make(map[string]json.RawMessage, len(ext.Schemas))
+	}
+
+	for name, raw := range ext.Schemas {
+		c.Schemas[name] = raw
+	}
+
+	return namespaces, nil
+}
+
+// Register adds catalog methods to handler forwarding calls to caller.
+func (c *Catalog) Register(h *jsonrpc.Handler, caller Caller) error {
+	schemas := h.OpenAPI.Reflector().SpecEns().ComponentsEns().SchemasEns()
+
+	for name, raw := range c.Schemas {
+		s := openapi3.SchemaOrRef{}
+
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("invalid schema %s: %w", name, err)
+		}
+
+		schemas.WithMapOfSchemaOrRefValuesItem(name, s)
+	}
+
+	for _, m := range c.Methods {
+		m := m
+
+		if !accessClasses[m.access()] {
+			return fmt.Errorf("%s: unknown access %q, expected %s, %s or %s", m.Name, m.Access, AccessRead, AccessWrite, AccessStateful)
+		}
+
+		u := usecase.NewIOI(new(json.RawMessage), new(json.RawMessage), forward(caller, m.Name))
+		u.SetName(m.Name)
+		u.SetTags(m.Tags...)
+		u.SetTitle(m.title())
+		u.SetDescription(m.Description)
+
+		h.OpenAPI.Annotate(m.Name, m.setupOperation)
+		h.Add(u)
+
+		if err := m.addSchemas(h); err != nil {
+			return fmt.Errorf("%s: %w", m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// access classifies method as read, write or stateful.
+func (m Method) access() string {
+	if m.Access != "" {
+		return m.Access
+	}
+
+	return methodAccess(m.Name)
+}
+
+// Access returns access classes of catalog methods.
+func (c *Catalog) Access() AccessClasses {
+	access := make(AccessClasses, len(c.Methods))
+
+	for _, m := range c.Methods {
+		access[m.Name] = m.access()
+	}
+
+	return access
+}
+
+// title returns summary with a badge of methods that are not read only, e.g. "[write] Sends ...".
+func (m Method) title() string {
+	if a := m.access(); a != AccessRead {
+		return "[" + a + "] " + m.Summary
+	}
+
+	return m.Summary
+}
+
+// params returns example params, never nil.
+func (ex Example) params() []json.RawMessage {
+	if ex.Params == nil {
+		return []json.RawMessage{}
+	}
+
+	return ex.Params
+}
+
+// setupOperation documents positional params, result and access class in OpenAPI operation.
+func (m Method) setupOperation(op *openapi3.Operation) error {
+	op.RequestBody = nil
+	op.WithMapOfAnythingItem("x-access", m.access())
+
+	if len(m.Params) > 0 {
+		schema, err := m.paramsSchema()
+		if err != nil {
+			return err
+		}
+
+		op.RequestBodyEns().RequestBodyEns().WithRequired(true).WithContentItem("application/json", openapi3.MediaType{
+			Schema:   &schema,
+			Examples: m.examples(func(ex Example) interface{} { return ex.params() }),
+		})
+	}
+
+	resp := openapi3.Response{Description: http.StatusText(http.StatusOK)}
+
+	if m.Result != nil {
+		schema, err := m.Result.schemaOrRef()
+		if err != nil {
+			return err
+		}
+
+		if m.Result.Description != "" {
+			resp.Description = m.Result.Description
+		}
+
+		resp.WithContentItem("application/json", openapi3.MediaType{
+			Schema: &schema,
+			Examples: m.examples(func(ex Example) interface{} {
+				if len(ex.Result) == 0 {
+					return nil
+				}
+
+				return ex.Result
+			}),
+		})
+	}
+
+	op.Responses.WithMapOfResponseOrRefValuesItem(strconv.Itoa(http.StatusOK), openapi3.ResponseOrRef{
+		Response: &resp,
+	})
+
+	return nil
+}
+
+// examples collects named OpenAPI examples from values of method examples, nil values are skipped.
+func (m Method) examples(value func(ex Example) interface{}) map[string]openapi3.ExampleOrRef {
+	var res map[string]openapi3.ExampleOrRef
+
+	for i, ex := range m.Examples {
+		v := value(ex)
+		if v == nil {
+			continue
+		}
+
+		if res == nil {
+			res = make(map[string]openapi3.ExampleOrRef)
+		}
+
+		res[ex.name(i)] = openapi3.ExampleOrRef{Example: &openapi3.Example{Value: &v}}
+	}
+
+	return res
+}
+
+// name returns example name, examples without name are numbered.
+func (ex Example) name(i int) string {
+	if ex.Name != "" {
+		return ex.Name
+	}
+
+	return fmt.Sprintf("example %d", i+1)
+}
+
+// ValidateExamples checks params and results of examples against method schemas.
+func (c *Catalog) ValidateExamples(v jsonrpc.Validator) []error {
+	var errs []error
+
+	for _, m := range c.Methods {
+		for i, ex := range m.Examples {
+			params, err := json.Marshal(ex.params())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", m.Name, ex.name(i), err))
+
+				continue
+			}
+
+			if err := v.ValidateParams(m.Name, params); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: invalid params: %s", m.Name, ex.name(i), validationMessage(err)))
+			}
+
+			if len(ex.Result) == 0 {
+				continue
+			}
+
+			if err := v.ValidateResult(m.Name, ex.Result); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: invalid result: %s", m.Name, ex.name(i), validationMessage(err)))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validationMessage lists issues of validation error.
+func validationMessage(err error) string {
+	var ve jsonrpc.ValidationErrors
+
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+
+	var issues []string
+
+	for _, v := range ve.Fields() {
+		if list, ok := v.([]string); ok {
+			issues = append(issues, list...)
+		}
+	}
+
+	sort.Strings(issues)
+
+	return strings.Join(issues, ", ")
+}
+
+// paramsSchema describes positional params as OpenAPI array, positions are listed in description.
+func (m Method) paramsSchema() (openapi3.SchemaOrRef, error) {
+	var (
+		items    []openapi3.SchemaOrRef
+		required int64
+		desc     = []string{"Positional params:"}
+		seen     = map[string]bool{}
+	)
+
+	for i, p := range m.Params {
+		schema, err := p.schemaOrRef()
+		if err != nil {
+			return openapi3.SchemaOrRef{}, err
+		}
+
+		line := fmt.Sprintf("%d. `%s`", i+1, p.Name)
+
+		if p.Required {
+			required = int64(i + 1)
+		} else {
+			line += " (optional)"
+		}
+
+		if p.Description != "" {
+			line += " " + p.Description
+		}
+
+		desc = append(desc, line)
+
+		if !seen[string(p.Schema)] {
+			seen[string(p.Schema)] = true
+
+			items = append(items, schema)
+		}
+	}
+
+	s := openapi3.Schema{}
+	s.WithType(openapi3.SchemaTypeArray)
+	s.WithMinItems(required)
+	s.WithMaxItems(int64(len(m.Params)))
+	s.WithDescription(strings.Join(desc, "\n"))
+
+	if len(items) == 1 {
+		s.Items = &items[0]
+	} else {
+		s.Items = &openapi3.SchemaOrRef{Schema: &openapi3.Schema{AnyOf: items}}
+	}
+
+	return openapi3.SchemaOrRef{Schema: &s}, nil
+}
+
+// addSchemas registers JSON Schemas of positional params and result for validation.
+func (m Method) addSchemas(h *jsonrpc.Handler) error {
+	if h.Validator == nil {
+		return nil
+	}
+
+	spec := h.OpenAPI.Reflector().Spec
+	components := map[string]interface{}{}
+
+	params := jsonschema.Schema{}
+	params.AddType(jsonschema.Array)
+	params.WithMaxItems(int64(len(m.Params)))
+
+	items := make([]jsonschema.SchemaOrBool, 0, len(m.Params))
+
+	for i, p := range m.Params {
+		schema, err := p.schemaOrRef()
+		if err != nil {
+			return err
+		}
+
+		items = append(items, jsonSchema(schema, spec, components))
+
+		if p.Required {
+			params.WithMinItems(int64(i + 1))
+		}
+	}
+
+	if len(items) > 0 {
+		params.ItemsEns().SchemaArray = items
+	}
+
+	params.WithExtraPropertiesItem("components", map[string]interface{}{"schemas": components})
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Validator.AddParamsSchema(m.Name, data); err != nil {
+		return fmt.Errorf("failed to add params schema: %w", err)
+	}
+
+	if m.Result == nil {
+		return nil
+	}
+
+	schema, err := m.Result.schemaOrRef()
+	if err != nil {
+		return err
+	}
+
+	components = map[string]interface{}{}
+	result := jsonSchema(schema, spec, components)
+	result.TypeObjectEns().WithExtraPropertiesItem("components", map[string]interface{}{"schemas": components})
+
+	if data, err = json.Marshal(result); err != nil {
+		return err
+	}
+
+	if err := h.Validator.AddResultSchema(m.Name, data); err != nil {
+		return fmt.Errorf("failed to add result schema: %w", err)
+	}
+
+	return nil
+}
+
+// schemaOrRef decodes OpenAPI schema of content.
+func (cd ContentDescriptor) schemaOrRef() (openapi3.SchemaOrRef, error) {
+	s := openapi3.SchemaOrRef{}
+
+	if len(cd.Schema) == 0 {
+		return s, fmt.Errorf("missing schema of %s", cd.Name)
+	}
+
+	if err := json.Unmarshal(cd.Schema, &s); err != nil {
+		return s, fmt.Errorf("invalid schema of %s: %w", cd.Name, err)
+	}
+
+	return s, nil
+}
+
+// jsonSchema converts OpenAPI schema to JSON Schema, referenced components are collected.
+func jsonSchema(s openapi3.SchemaOrRef, spec *openapi3.Spec, components map[string]interface{}) jsonschema.SchemaOrBool {
+	js := s.ToJSONSchema(spec)
+
+	if js.TypeObject == nil {
+		return js
+	}
+
+	if c, ok := js.TypeObject.ExtraProperties["components"].(map[string]interface{}); ok {
+		if schemas, ok := c["schemas"].(map[string]jsonschema.SchemaOrBool); ok {
+			for name, schema := range schemas {
+				components[name] = schema
+			}
+		}
+
+		delete(js.TypeObject.ExtraProperties, "components")
+	}
+
+	return js
+}
+
+// yamlToJSON converts YAML (or JSON) document to JSON.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonValue(doc))
+}
+
+// jsonValue converts YAML maps to JSON compatible maps.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+
+		for k, val := range v {
+			m[fmt.Sprint(k)] = jsonValue(val)
+		}
+
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = jsonValue(val)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCatalogExtend(t *testing.T) {
+	base := func() *Catalog {
+		return &Catalog{
+			Schemas: map[string]json.RawMessage{"Peer": json.RawMessage(`{}`)},
+			Methods: []Method{{Name: "eth_chainId"}, {Name: "net_version"}},
+		}
+	}
+
+	for _, tc := range []struct {
+		name       string
+		ext        Catalog
+		namespaces []string
+		err        bool
+	}{
+		{
+			name: "new namespaces",
+			ext: Catalog{
+				Schemas: map[string]json.RawMessage{"Validator": json.RawMessage(`{}`)},
+				Methods: []Method{{Name: "cosmos_account"}, {Name: "txpool_status"}, {Name: "cosmos_sign"}},
+			},
+			namespaces: []string{"cosmos", "txpool"},
+		},
+		{
+			name: "namespace in catalog",
+			ext:  Catalog{Methods: []Method{{Name: "cosmos_account"}, {Name: "eth_foo"}}},
+			err:  true,
+		},
+		{
+			name: "duplicate schema",
+			ext: Catalog{
+				Schemas: map[string]json.RawMessage{"Peer": json.RawMessage(`{}`)},
+				Methods: []Method{{Name: "cosmos_account"}},
+			},
+			err: true,
+		},
+		{
+			name: "different types",
+			ext:  Catalog{Types: TypesTendermint, Methods: []Method{{Name: "status"}}},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := base()
+
+			namespaces, err := c.Extend(&tc.ext)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				// Failed extension leaves catalog as is.
+				if len(c.Methods) != 2 || len(c.Schemas) != 1 {
+					t.Errorf("catalog changed: %d methods, %d schemas", len(c.Methods), len(c.Schemas))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(namespaces) != len(tc.namespaces) {
+				t.Fatalf("got namespaces %v, want %v", namespaces, tc.namespaces)
+			}
+
+			for i := range namespaces {
+				if namespaces[i] != tc.namespaces[i] {
+					t.Errorf("got namespaces %v, want %v", namespaces, tc.namespaces)
+				}
+			}
+
+			if len(c.Methods) != 2+len(tc.ext.Methods) || len(c.Schemas) != 1+len(tc.ext.Schemas) {
+				t.Errorf("got %d methods, %d schemas", len(c.Methods), len(c.Schemas))
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is a server configuration, flags default to environment variables.
+type Config struct {
+	Listen        string
+	TLSCert       string
+	TLSKey        string
+	TLSSelfSigned bool
+
+	RPCPath     string
+	WSPath      string
+	DocsPath    string
+	SpecPath    string
+	OpenRPCPath string
+
+	TendermintRPCPath  string
+	TendermintDocsPath string
+	TendermintSpecPath string
+
+	NetworksFile string
+	CatalogFile  string
+	UIAssets     string
+	Mock         bool
+	Dev          bool
+	ReadOnly     bool
+	RecordFile   string
+	ReplayFile   string
+
+	ExtensionFiles        []string
+	TendermintCatalogFile string
+
+	// APIKeysFile lists keys that JSON-RPC endpoints and docs require, empty to leave them open.
+	APIKeysFile string
+
+	// DebugVars serves metrics at /debug/vars, they require API key when keys are configured.
+	DebugVars bool
+
+	// Methods are allowed or denied on every network, denied methods are neither documented nor served.
+	Methods MethodRules
+
+	BatchConcurrency  int
+	BatchLimit        int
+	UpstreamTimeout   time.Duration
+	FilterTimeout     time.Duration
+	HealthInterval    time.Duration
+	HealthTimeout     time.Duration
+	CallTimeout       time.Duration
+	Retries           int
+	ChecksumAddresses bool
+	ResultValidation  string
+}
+
+// ParseConfig reads configuration from command line arguments and environment.
+func ParseConfig(fs *flag.FlagSet, args []string) (Config, error) {
+	var (
+		c   Config
+		err error
+	)
+
+	fs.StringVar(&c.Listen, "listen", env("LISTEN_ADDR", ":8080"), "Listen address, env LISTEN_ADDR")
+	fs.StringVar(&c.TLSCert, "tls-cert", env("TLS_CERT_FILE", ""), "TLS certificate file, env TLS_CERT_FILE")
+	fs.StringVar(&c.TLSKey, "tls-key", env("TLS_KEY_FILE", ""), "TLS private key file, env TLS_KEY_FILE")
+
+	selfSigned, err := envBool("TLS_SELF_SIGNED")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.TLSSelfSigned, "tls-self-signed", selfSigned,
+		"Serve TLS with a generated self-signed certificate for localhost (development only), env TLS_SELF_SIGNED")
+
+	fs.StringVar(&c.RPCPath, "rpc-path", env("RPC_PATH", "/rpc"), "JSON-RPC endpoint path, env RPC_PATH")
+	fs.StringVar(&c.WSPath, "ws-path", env("WS_PATH", "/ws"), "JSON-RPC WebSocket endpoint path, env WS_PATH")
+	fs.StringVar(&c.DocsPath, "docs-path", env("DOCS_PATH", "/docs/swagger"), "Swagger UI path, env DOCS_PATH")
+	fs.StringVar(&c.SpecPath, "spec-path", env("SPEC_PATH", "/docs/swagger/jsonrpc.json"), "OpenAPI spec path, env SPEC_PATH")
+	fs.StringVar(&c.OpenRPCPath, "openrpc-path", env("OPENRPC_PATH", "/openrpc.json"), "OpenRPC document path, env OPENRPC_PATH")
+
+	fs.StringVar(&c.TendermintRPCPath, "tendermint-rpc-path", env("TENDERMINT_RPC_PATH", "/tendermint"),
+		"Tendermint RPC endpoint path, env TENDERMINT_RPC_PATH")
+	fs.StringVar(&c.TendermintDocsPath, "tendermint-docs-path", env("TENDERMINT_DOCS_PATH", "/docs/tendermint"),
+		"Tendermint RPC Swagger UI path, env TENDERMINT_DOCS_PATH")
+	fs.StringVar(&c.TendermintSpecPath, "tendermint-spec-path", env("TENDERMINT_SPEC_PATH", "/docs/tendermint/jsonrpc.json"),
+		"Tendermint RPC OpenAPI spec path, env TENDERMINT_SPEC_PATH")
+
+	fs.StringVar(&c.NetworksFile, "networks", env("NETWORKS_FILE", "networks.yaml"),
+		"YAML or JSON file with networks that calls are forwarded to, env NETWORKS_FILE")
+	fs.StringVar(&c.CatalogFile, "catalog", env("CATALOG_FILE", "catalog.yaml"),
+		"YAML or JSON file with JSON-RPC methods catalog, env CATALOG_FILE")
+
+	extensions := env("EXTENSION_FILES", "ethermint.yaml")
+	fs.StringVar(&extensions, "extensions", extensions,
+		"Comma separated catalog files with namespaces of chain specific methods, networks serve them "+
+			"only when enabled in namespaces, env EXTENSION_FILES")
+	fs.StringVar(&c.TendermintCatalogFile, "tendermint-catalog", env("TENDERMINT_CATALOG_FILE", "tendermint.yaml"),
+		"YAML or JSON file with Tendermint RPC methods catalog, empty to serve no Tendermint docs, env TENDERMINT_CATALOG_FILE")
+	fs.StringVar(&c.UIAssets, "ui", env("UI_ASSETS", "cdn"),
+		"Swagger UI assets source: cdn, or embedded to serve them from the binary for offline use, env UI_ASSETS")
+
+	mock, err := envBool("MOCK")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.Mock, "mock", mock,
+		"Answer calls of every network with an in-memory mock node instead of upstream, env MOCK")
+
+	dev, err := envBool("DEV_MODE")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.Dev, "dev", dev,
+		"Serve personal_ methods that manage node keys, never enable it for shared nodes, env DEV_MODE")
+
+	readOnly, err := envBool("READ_ONLY")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.ReadOnly, "read-only", readOnly,
+		"Reject methods that send or sign transactions or change node configuration, env READ_ONLY")
+
+	fs.StringVar(&c.RecordFile, "record", env("RECORD_FILE", ""),
+		"JSONL cassette file to append calls and responses to, env RECORD_FILE")
+	fs.StringVar(&c.ReplayFile, "replay", env("REPLAY_FILE", ""),
+		"JSONL cassette file to answer calls from instead of the network backends, env REPLAY_FILE")
+
+	batchConcurrency, err := strconv.Atoi(env("BATCH_CONCURRENCY", "4"))
+	if err != nil {
+		return c, fmt.Errorf("invalid BATCH_CONCURRENCY: %w", err)
+	}
+
+	fs.IntVar(&c.BatchConcurrency, "batch-concurrency", batchConcurrency,
+		"Maximum number of batch request items executed in parallel, env BATCH_CONCURRENCY")
+
+	batchLimit, err := strconv.Atoi(env("BATCH_LIMIT", "100"))
+	if err != nil {
+		return c, fmt.Errorf("invalid BATCH_LIMIT: %w", err)
+	}
+
+	fs.IntVar(&c.BatchLimit, "batch-limit", batchLimit,
+		"Maximum number of items in a batch request, larger batches are rejected, env BATCH_LIMIT")
+
+	upstreamTimeout, err := time.ParseDuration(env("UPSTREAM_TIMEOUT", "30s"))
+	if err != nil {
+		return c, fmt.Errorf("invalid UPSTREAM_TIMEOUT: %w", err)
+	}
+
+	fs.DurationVar(&c.UpstreamTimeout, "upstream-timeout", upstreamTimeout,
+		"Timeout of calls forwarded to upstream nodes, env UPSTREAM_TIMEOUT")
+
+	filterTimeout, err := time.ParseDuration(env("FILTER_TIMEOUT", "5m"))
+	if err != nil {
+		return c, fmt.Errorf("invalid FILTER_TIMEOUT: %w", err)
+	}
+
+	fs.DurationVar(&c.FilterTimeout, "filter-timeout", filterTimeout,
+		"Idle timeout of filters kept on the server, 0 to forward filter methods to the network backends, env FILTER_TIMEOUT")
+
+	healthInterval, err := time.ParseDuration(env("HEALTH_INTERVAL", "15s"))
+	if err != nil {
+		return c, fmt.Errorf("invalid HEALTH_INTERVAL: %w", err)
+	}
+
+	fs.DurationVar(&c.HealthInterval, "health-interval", healthInterval,
+		"Interval of eth_blockNumber health checks of networks with several rpcUrls, env HEALTH_INTERVAL")
+
+	healthTimeout, err := time.ParseDuration(env("HEALTH_TIMEOUT", "5s"))
+	if err != nil {
+		return c, fmt.Errorf("invalid HEALTH_TIMEOUT: %w", err)
+	}
+
+	fs.DurationVar(&c.HealthTimeout, "health-timeout", healthTimeout,
+		"Timeout of health checks, nodes that do not answer in time are unhealthy, env HEALTH_TIMEOUT")
+
+	callTimeout, err := time.ParseDuration(env("CALL_TIMEOUT", "10s"))
+	if err != nil {
+		return c, fmt.Errorf("invalid CALL_TIMEOUT: %w", err)
+	}
+
+	fs.DurationVar(&c.CallTimeout, "call-timeout", callTimeout,
+		"Timeout of a call to a node of networks with several rpcUrls, read calls are retried on another node, "+
+			"env CALL_TIMEOUT")
+
+	retries, err := strconv.Atoi(env("RETRIES", "2"))
+	if err != nil {
+		return c, fmt.Errorf("invalid RETRIES: %w", err)
+	}
+
+	fs.IntVar(&c.Retries, "retries", retries,
+		"Number of other upstream nodes that read calls are retried on when a node can not be reached, env RETRIES")
+
+	fs.StringVar(&c.APIKeysFile, "api-keys", env("API_KEYS_FILE", ""),
+		"YAML or JSON file with API keys that JSON-RPC endpoints, docs and specs require, empty to leave them open, "+
+			"env API_KEYS_FILE")
+
+	debugVars, err := envBool("DEBUG_VARS")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.DebugVars, "debug-vars", debugVars,
+		"Serve metrics at /debug/vars, they require API key when keys are configured, env DEBUG_VARS")
+
+	allowMethods := env("ALLOW_METHODS", "")
+	fs.StringVar(&allowMethods, "allow-methods", allowMethods,
+		"Comma separated patterns of methods to serve, e.g. eth_*,net_version, empty to serve all, env ALLOW_METHODS")
+
+	denyMethods := env("DENY_METHODS", "")
+	fs.StringVar(&denyMethods, "deny-methods", denyMethods,
+		"Comma separated patterns of methods not to serve, e.g. debug_*, env DENY_METHODS")
+
+	checksumAddresses, err := envBool("CHECKSUM_ADDRESSES")
+	if err != nil {
+		return c, err
+	}
+
+	fs.BoolVar(&c.ChecksumAddresses, "checksum-addresses", checksumAddresses,
+		"Reject mixed case address params with invalid EIP-55 checksum, env CHECKSUM_ADDRESSES")
+
+	fs.StringVar(&c.ResultValidation, "result-validation", env("RESULT_VALIDATION", ResultValidationOff),
+		"Validation of results against method schemas: off, report to log and metrics, or strict to also respond with error, "+
+			"env RESULT_VALIDATION")
+
+	if err = fs.Parse(args); err != nil {
+		return c, err
+	}
+
+	c.ExtensionFiles = splitList(extensions)
+	c.Methods = MethodRules{Allow: splitList(allowMethods), Deny: splitList(denyMethods)}
+
+	if err := c.Methods.Validate(); err != nil {
+		return c, err
+	}
+
+	if c.BatchConcurrency < 1 {
+		return c, errors.New("batch concurrency must be positive")
+	}
+
+	if c.BatchLimit < 1 {
+		return c, errors.New("batch limit must be positive")
+	}
+
+	if c.UpstreamTimeout <= 0 {
+		return c, errors.New("upstream timeout must be positive")
+	}
+
+	if c.HealthInterval <= 0 {
+		return c, errors.New("health interval must be positive")
+	}
+
+	if c.HealthTimeout <= 0 {
+		return c, errors.New("health timeout must be positive")
+	}
+
+	if c.CallTimeout <= 0 {
+		return c, errors.New("call timeout must be positive")
+	}
+
+	if c.Retries < 0 {
+		return c, errors.New("retries must not be negative")
+	}
+
+	switch c.ResultValidation {
+	case ResultValidationOff, ResultValidationReport, ResultValidationStrict:
+	default:
+		return c, fmt.Errorf("invalid result validation mode %q, expected off, report or strict", c.ResultValidation)
+	}
+
+	if c.RecordFile != "" && c.ReplayFile != "" {
+		return c, errors.New("record and replay cassettes are mutually exclusive")
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return c, errors.New("both TLS certificate and key files are required")
+	}
+
+	for _, p := range []*string{
+		&c.RPCPath, &c.WSPath, &c.DocsPath, &c.SpecPath, &c.OpenRPCPath,
+		&c.TendermintRPCPath, &c.TendermintDocsPath, &c.TendermintSpecPath,
+	} {
+		*p = "/" + strings.Trim(*p, "/")
+	}
+
+	return c, nil
+}
+
+// TLS tells if server is served with TLS.
+func (c Config) TLS() bool {
+	return c.TLSSelfSigned || c.TLSCert != ""
+}
+
+// URL returns local URL of a path.
+func (c Config) URL(path string) string {
+	scheme := "http"
+	if c.TLS() {
+		scheme = "https"
+	}
+
+	host, port, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return scheme + "://" + c.Listen + path
+	}
+
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	return scheme + "://" + net.JoinHostPort(host, port) + path
+}
+
+// splitList returns non-empty items of comma separated list.
+func splitList(s string) []string {
+	var items []string
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// env returns value of environment variable or default.
+func env(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+
+	return def
+}
+
+// envBool returns boolean value of environment variable, false if it is not set.
+func envBool(name string) (bool, error) {
+	v, err := strconv.ParseBool(env(name, "false"))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	return v, nil
+}
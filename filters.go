@@ -0,0 +1,440 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Limits of emulated filters.
+const (
+	// filterMaxBlocks limits number of blocks fetched by a single eth_getFilterChanges call,
+	// block and pending transaction filters skip older blocks, log filters get them on next polls.
+	filterMaxBlocks = 256
+	// filterMaxFilters limits number of filters installed on a network, idle filters are removed first.
+	filterMaxFilters = 10000
+)
+
+// FilterEmulator keeps filter state on the server and answers filter methods
+// with stateless upstream calls, so that filters work with load-balanced upstreams.
+//
+// Filter changes are blocks, logs and transactions since the last poll, pending
+// transaction filters report transactions once they are included in a block.
+type FilterEmulator struct {
+	Next    Caller
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	filters map[string]*emulatedFilter
+}
+
+type emulatedFilter struct {
+	kind      int
+	query     FilterObject
+	lastBlock uint64
+	lastUsed  time.Time
+}
+
+// FilterParams are params of log queries and log filter creation.
+type FilterParams struct {
+	Filter FilterObject `json:"filter"`
+}
+
+// UnmarshalJSON decodes positional params.
+func (p *FilterParams) UnmarshalJSON(data []byte) error {
+	return unmarshalPositional(data, 1, &p.Filter)
+}
+
+// FilterIDParams are params of methods operating on an installed filter.
+type FilterIDParams struct {
+	FilterID Quantity `json:"filterId"`
+}
+
+// UnmarshalJSON decodes positional params.
+func (p *FilterIDParams) UnmarshalJSON(data []byte) error {
+	return unmarshalPositional(data, 1, &p.FilterID)
+}
+
+// CallParams are params of eth_call and eth_estimateGas.
+type CallParams struct {
+	Call  TransactionArgs `json:"call"`
+	Block *BlockTag       `json:"block,omitempty"`
+}
+
+// UnmarshalJSON decodes positional params.
+func (p *CallParams) UnmarshalJSON(data []byte) error {
+	return unmarshalPositional(data, 1, &p.Call, &p.Block)
+}
+
+// NewFilterEmulator creates filter emulator, filters that are not polled for timeout are removed.
+func NewFilterEmulator(next Caller, timeout time.Duration) *FilterEmulator {
+	return &FilterEmulator{
+		Next:    next,
+		Timeout: timeout,
+		filters: map[string]*emulatedFilter{},
+	}
+}
+
+// Call answers filter methods and forwards other calls.
+func (f *FilterEmulator) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch method {
+	case "eth_newFilter":
+		result, err = f.newFilter(ctx, params)
+	case "eth_newBlockFilter":
+		result, err = f.add(ctx, &emulatedFilter{kind: blockFilter})
+	case "eth_newPendingTransactionFilter":
+		result, err = f.add(ctx, &emulatedFilter{kind: pendingFilter})
+	case "eth_uninstallFilter":
+		result, err = f.uninstall(params)
+	case "eth_getFilterChanges":
+		result, err = f.changes(ctx, params)
+	case "eth_getFilterLogs":
+		result, err = f.logs(ctx, params)
+	default:
+		return f.Next.Call(ctx, method, params)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+func (f *FilterEmulator) newFilter(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p FilterParams
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams("filter object expected: %v", err)
+	}
+
+	if p.Filter.BlockHash != nil {
+		return nil, invalidParams("blockHash is not supported by filters")
+	}
+
+	return f.add(ctx, &emulatedFilter{kind: logFilter, query: p.Filter})
+}
+
+func (f *FilterEmulator) add(ctx context.Context, filter *emulatedFilter) (interface{}, error) {
+	head, err := f.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	filter.lastBlock = head
+	filter.lastUsed = time.Now()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.expire()
+
+	if len(f.filters) >= filterMaxFilters {
+		return nil, UpstreamError{
+			Code:    codeLimitExceeded,
+			Message: fmt.Sprintf("%d filters are installed, uninstall a filter to create a new one", filterMaxFilters),
+		}
+	}
+
+	// Filter id is a quantity, so it has no leading zeros.
+	key := string(hexBig(new(big.Int).SetBytes(id)))
+	f.filters[key] = filter
+
+	return key, nil
+}
+
+// expire removes idle filters, lock must be held.
+func (f *FilterEmulator) expire() {
+	for id, filter := range f.filters {
+		if time.Since(filter.lastUsed) > f.Timeout {
+			delete(f.filters, id)
+		}
+	}
+}
+
+// filter returns a copy of filter state by id from params.
+func (f *FilterEmulator) filter(params json.RawMessage) (string, emulatedFilter, error) {
+	var p FilterIDParams
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "", emulatedFilter{}, invalidParams("filter id expected: %v", err)
+	}
+
+	id := string(p.FilterID)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.expire()
+
+	filter, ok := f.filters[id]
+	if !ok {
+		return id, emulatedFilter{}, execError("filter not found")
+	}
+
+	filter.lastUsed = time.Now()
+
+	return id, *filter, nil
+}
+
+func (f *FilterEmulator) uninstall(params json.RawMessage) (interface{}, error) {
+	id, _, err := f.filter(params)
+	if err != nil {
+		return false, nil // nolint:nilerr // Unknown filter is reported with false result.
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.filters, id)
+
+	return true, nil
+}
+
+func (f *FilterEmulator) changes(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	id, filter, err := f.filter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := f.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, ok := f.claim(id, filter.kind, head)
+	if !ok {
+		return FilterChanges{}, nil
+	}
+
+	var (
+		changes interface{}
+		last    = to
+	)
+
+	switch filter.kind {
+	case logFilter:
+		changes, err = f.newLogs(ctx, filter.query, from, to)
+	default:
+		changes, last, err = f.newBlocks(ctx, filter.kind, from, to)
+	}
+
+	if err != nil {
+		last = from - 1
+	}
+
+	if last < to {
+		f.release(id, last, to)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// claim moves filter to the last block of the next poll window and returns the window,
+// so that concurrent polls of the same filter get different blocks. Block and pending
+// transaction filters skip blocks older than filterMaxBlocks, log filters catch up
+// over several polls.
+func (f *FilterEmulator) claim(id string, kind int, head uint64) (from, to uint64, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	filter, found := f.filters[id]
+	if !found || head <= filter.lastBlock {
+		return 0, 0, false
+	}
+
+	from, to = filter.lastBlock+1, head
+
+	if head-filter.lastBlock > filterMaxBlocks {
+		if kind == logFilter {
+			to = filter.lastBlock + filterMaxBlocks
+		} else {
+			from = head - filterMaxBlocks + 1
+		}
+	}
+
+	filter.lastBlock = to
+
+	return from, to, true
+}
+
+// release moves filter back to the last returned block if the claimed window was not
+// fetched completely and no later poll claimed blocks after it.
+func (f *FilterEmulator) release(id string, last, claimed uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if filter, ok := f.filters[id]; ok && filter.lastBlock == claimed {
+		filter.lastBlock = last
+	}
+}
+
+// newLogs fetches logs of blocks from-to that match the query.
+func (f *FilterEmulator) newLogs(ctx context.Context, query FilterObject, from, to uint64) (json.RawMessage, error) {
+	if n, ok := blockTagNumber(query.FromBlock); ok && n > from {
+		from = n
+	}
+
+	if n, ok := blockTagNumber(query.ToBlock); ok && n < to {
+		to = n
+	}
+
+	if from > to {
+		return json.RawMessage("[]"), nil
+	}
+
+	fromTag, toTag := BlockTag(hexUint(from)), BlockTag(hexUint(to))
+	query.FromBlock, query.ToBlock = &fromTag, &toTag
+
+	var logs json.RawMessage
+
+	if err := f.call(ctx, "eth_getLogs", []interface{}{query}, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// newBlocks fetches hashes of blocks from-to, or of their transactions, it returns
+// number of the last fetched block, which is less than to if upstream does not have the next one yet.
+func (f *FilterEmulator) newBlocks(ctx context.Context, kind int, from, to uint64) (FilterChanges, uint64, error) {
+	changes := FilterChanges{}
+
+	for n := from; n <= to; n++ {
+		var b *struct {
+			Hash         Hash   `json:"hash"`
+			Transactions []Hash `json:"transactions"`
+		}
+
+		if err := f.call(ctx, "eth_getBlockByNumber", []interface{}{hexUint(n), false}, &b); err != nil {
+			return nil, 0, err
+		}
+
+		if b == nil {
+			return changes, n - 1, nil
+		}
+
+		if kind == blockFilter {
+			changes = append(changes, LogOrHash{Hash: &b.Hash})
+
+			continue
+		}
+
+		for i := range b.Transactions {
+			changes = append(changes, LogOrHash{Hash: &b.Transactions[i]})
+		}
+	}
+
+	return changes, to, nil
+}
+
+func (f *FilterEmulator) logs(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	_, filter, err := f.filter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.kind != logFilter {
+		return nil, execError("filter not found")
+	}
+
+	var logs json.RawMessage
+
+	if err := f.call(ctx, "eth_getLogs", []interface{}{filter.query}, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (f *FilterEmulator) blockNumber(ctx context.Context) (uint64, error) {
+	var head Quantity
+
+	if err := f.call(ctx, "eth_blockNumber", []interface{}{}, &head); err != nil {
+		return 0, err
+	}
+
+	n, err := parseUint(head)
+	if err != nil {
+		return 0, execError("invalid block number: %v", err)
+	}
+
+	return n, nil
+}
+
+// call invokes upstream method and decodes its result.
+func (f *FilterEmulator) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	res, err := f.Next.Call(ctx, method, p)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(res, result); err != nil {
+		return execError("unexpected %s result: %v", method, err)
+	}
+
+	return nil
+}
+
+// blockTagNumber returns block number of a tag, false for named tags.
+func blockTagNumber(tag *BlockTag) (uint64, bool) {
+	if tag == nil {
+		return 0, false
+	}
+
+	if *tag == "earliest" {
+		return 0, true
+	}
+
+	n, err := parseUint(Quantity(*tag))
+
+	return n, err == nil
+}
+
+// unmarshalPositional decodes params array into fields, only first required fields are mandatory.
+func unmarshalPositional(data []byte, required int, fields ...interface{}) error {
+	var items []json.RawMessage
+
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+
+	if len(items) < required {
+		return fmt.Errorf("missing value for required argument %d", len(items))
+	}
+
+	if len(items) > len(fields) {
+		return fmt.Errorf("too many arguments, want at most %d", len(fields))
+	}
+
+	for i, item := range items {
+		if err := json.Unmarshal(item, fields[i]); err != nil {
+			return fmt.Errorf("invalid argument %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
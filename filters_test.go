@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testChain answers calls that filter emulator makes.
+type testChain struct {
+	mu        sync.Mutex
+	head      uint64 // Block number reported by eth_blockNumber.
+	available uint64 // Last block returned by eth_getBlockByNumber.
+	fail      bool   // Fail next eth_getLogs call.
+	delay     time.Duration
+}
+
+func (c *testChain) set(head, available uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.head, c.available = head, available
+}
+
+func (c *testChain) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch method {
+	case "eth_blockNumber":
+		return json.Marshal(hexUint(c.head))
+	case "eth_getBlockByNumber":
+		var p []interface{}
+
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+
+		n, err := parseUint(Quantity(p[0].(string)))
+		if err != nil {
+			return nil, err
+		}
+
+		time.Sleep(c.delay)
+
+		if n > c.available {
+			return json.RawMessage("null"), nil
+		}
+
+		return json.RawMessage(fmt.Sprintf(`{"hash":"%d","transactions":[]}`, n)), nil
+	case "eth_getLogs":
+		if c.fail {
+			c.fail = false
+
+			return nil, errors.New("upstream failed")
+		}
+
+		var p []FilterObject
+
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+
+		// Queried range is returned instead of logs.
+		return json.Marshal([]BlockTag{*p[0].FromBlock, *p[0].ToBlock})
+	}
+
+	return nil, methodNotFound(method)
+}
+
+// poll returns filter changes as JSON.
+func poll(t *testing.T, f *FilterEmulator, id string) string {
+	t.Helper()
+
+	res, err := f.Call(context.Background(), "eth_getFilterChanges", json.RawMessage(`["`+id+`"]`))
+	if err != nil {
+		return "error"
+	}
+
+	return string(res)
+}
+
+func hashes(from, to uint64) string {
+	s := "["
+	for n := from; n <= to; n++ {
+		if n > from {
+			s += ","
+		}
+
+		s += fmt.Sprintf(`"%d"`, n)
+	}
+
+	return s + "]"
+}
+
+func TestFilterChanges(t *testing.T) {
+	type step struct {
+		head, available uint64
+		fail            bool
+		want            string
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		params string
+		steps  []step
+	}{
+		{
+			name:   "new blocks",
+			method: "eth_newBlockFilter",
+			steps: []step{
+				{head: 13, available: 13, want: hashes(11, 13)},
+				{head: 13, available: 13, want: `[]`},
+				{head: 14, available: 14, want: hashes(14, 14)},
+			},
+		},
+		{
+			name:   "old blocks are skipped",
+			method: "eth_newBlockFilter",
+			steps: []step{
+				{head: 10 + filterMaxBlocks + 44, available: 1000, want: hashes(55, 10+filterMaxBlocks+44)},
+			},
+		},
+		{
+			name:   "block not yet available",
+			method: "eth_newBlockFilter",
+			steps: []step{
+				{head: 15, available: 12, want: hashes(11, 12)},
+				{head: 15, available: 15, want: hashes(13, 15)},
+			},
+		},
+		{
+			name:   "log window",
+			method: "eth_newFilter",
+			params: `[{}]`,
+			steps: []step{
+				{head: 12, want: `["0xb","0xc"]`},
+				{head: 12, want: `[]`},
+			},
+		},
+		{
+			name:   "log window is capped",
+			method: "eth_newFilter",
+			params: `[{}]`,
+			steps: []step{
+				{head: 600, want: `["0xb","0x10a"]`},
+				{head: 600, want: `["0x10b","0x20a"]`},
+				{head: 600, want: `["0x20b","0x258"]`},
+			},
+		},
+		{
+			name:   "log query range",
+			method: "eth_newFilter",
+			params: `[{"fromBlock":"0xc","toBlock":"0xe"}]`,
+			steps: []step{
+				{head: 20, want: `["0xc","0xe"]`},
+				{head: 30, want: `[]`},
+			},
+		},
+		{
+			name:   "failed poll is repeated",
+			method: "eth_newFilter",
+			params: `[{}]`,
+			steps: []step{
+				{head: 12, fail: true, want: `error`},
+				{head: 13, want: `["0xb","0xd"]`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := &testChain{head: 10, available: 10}
+			f := NewFilterEmulator(chain, time.Minute)
+
+			params := tc.params
+			if params == "" {
+				params = `[]`
+			}
+
+			res, err := f.Call(context.Background(), tc.method, json.RawMessage(params))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var id string
+
+			if err := json.Unmarshal(res, &id); err != nil {
+				t.Fatal(err)
+			}
+
+			if !regexp.MustCompile(quantityPattern).MatchString(id) {
+				t.Errorf("filter id %s is not a quantity", id)
+			}
+
+			for i, s := range tc.steps {
+				chain.set(s.head, s.available)
+				chain.fail = s.fail
+
+				if got := poll(t, f, id); got != s.want {
+					t.Errorf("poll %d: got %s, want %s", i+1, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterChangesConcurrent(t *testing.T) {
+	chain := &testChain{head: 10, available: 10}
+	f := NewFilterEmulator(chain, time.Minute)
+
+	res, err := f.Call(context.Background(), "eth_newBlockFilter", json.RawMessage(`[]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var id string
+
+	if err := json.Unmarshal(res, &id); err != nil {
+		t.Fatal(err)
+	}
+
+	chain.set(30, 30)
+	chain.delay = time.Millisecond
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		seen = map[Hash]int{}
+	)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var changes []Hash
+
+			if err := json.Unmarshal([]byte(poll(t, f, id)), &changes); err != nil {
+				t.Error(err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, h := range changes {
+				seen[h]++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(seen) != 20 {
+		t.Errorf("got %d blocks, want 20", len(seen))
+	}
+
+	for h, n := range seen {
+		if n > 1 {
+			t.Errorf("block %s is returned %d times", h, n)
+		}
+	}
+}
+
+func TestPositionalParams(t *testing.T) {
+	var call CallParams
+
+	if err := json.Unmarshal([]byte(`[{"to":"0x0000000000000000000000000000000000000001"},"latest"]`), &call); err != nil {
+		t.Fatal(err)
+	}
+
+	if call.Call.To == nil || *call.Call.To != "0x0000000000000000000000000000000000000001" || call.Block == nil || *call.Block != "latest" {
+		t.Errorf("unexpected params %+v", call)
+	}
+
+	call = CallParams{}
+	if err := json.Unmarshal([]byte(`[{}]`), &call); err != nil || call.Block != nil {
+		t.Errorf("optional param omitted: %+v, %v", call, err)
+	}
+
+	var filter FilterParams
+	if err := json.Unmarshal([]byte(`[{"fromBlock":"0x1"}]`), &filter); err != nil || filter.Filter.FromBlock == nil {
+		t.Errorf("filter params: %+v, %v", filter, err)
+	}
+
+	for _, data := range []string{`[]`, `["0x1","0x2"]`, `{"filterId":"0x1"}`, `[true]`} {
+		var p FilterIDParams
+		if err := json.Unmarshal([]byte(data), &p); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
+
+func TestFilterLimit(t *testing.T) {
+	f := NewFilterEmulator(&testChain{head: 10, available: 10}, time.Minute)
+	ctx := context.Background()
+
+	for i := 1; i < filterMaxFilters; i++ {
+		f.filters[fmt.Sprintf("0x%x", i)] = &emulatedFilter{kind: blockFilter, lastUsed: time.Now()}
+	}
+
+	if _, err := f.Call(ctx, "eth_newBlockFilter", json.RawMessage(`[]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := f.Call(ctx, "eth_newFilter", json.RawMessage(`[{}]`))
+
+	var upstreamErr UpstreamError
+	if !errors.As(err, &upstreamErr) || upstreamErr.Code != codeLimitExceeded {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Idle filters are removed before the limit is checked.
+	f.mu.Lock()
+	for _, filter := range f.filters {
+		filter.lastUsed = time.Now().Add(-2 * time.Minute)
+	}
+	f.mu.Unlock()
+
+	if _, err := f.Call(ctx, "eth_newBlockFilter", json.RawMessage(`[]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(f.filters); n != 1 {
+		t.Errorf("expected 1 installed filter, got %d", n)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	validator "github.com/santhosh-tekuri/jsonschema/v2"
+	"github.com/swaggest/jsonrpc"
+)
+
+// addressChecksumFormat replaces address format in params schemas when checksums are required.
+const addressChecksumFormat = "address-checksum"
+
+// ChecksumValidator rejects params with mixed case addresses that have invalid EIP-55 checksum,
+// results are validated by the wrapped validator as is.
+type ChecksumValidator struct {
+	jsonrpc.Validator
+}
+
+// NewChecksumValidator wraps validator to check address checksums in params.
+func NewChecksumValidator(v jsonrpc.Validator) ChecksumValidator {
+	validator.Formats[addressChecksumFormat] = func(value interface{}) bool {
+		s, ok := value.(string)
+
+		return !ok || validChecksum(s)
+	}
+
+	return ChecksumValidator{Validator: v}
+}
+
+// AddParamsSchema registers params schema with checksum format of addresses.
+func (v ChecksumValidator) AddParamsSchema(method string, jsonSchema []byte) error {
+	var schema interface{}
+
+	if err := json.Unmarshal(jsonSchema, &schema); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(withChecksumFormat(schema))
+	if err != nil {
+		return err
+	}
+
+	return v.Validator.AddParamsSchema(method, data)
+}
+
+// withChecksumFormat replaces address format with checksum format in decoded schema.
+func withChecksumFormat(schema interface{}) interface{} {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		for k, v := range s {
+			if k == "format" && v == "address" {
+				s[k] = addressChecksumFormat
+			} else {
+				s[k] = withChecksumFormat(v)
+			}
+		}
+	case []interface{}:
+		for i, v := range s {
+			s[i] = withChecksumFormat(v)
+		}
+	}
+
+	return schema
+}
+
+// validChecksum tells whether address is in a single case or has a valid EIP-55 checksum,
+// malformed addresses are left to the pattern.
+func validChecksum(addr string) bool {
+	if len(addr) != 42 || !strings.HasPrefix(addr, "0x") {
+		return true
+	}
+
+	digits := addr[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+
+	return addr == checksumAddress(addr)
+}
+
+// checksumAddress returns EIP-55 mixed case encoding of a hex address.
+func checksumAddress(addr string) string {
+	digits := []byte(strings.ToLower(strings.TrimPrefix(addr, "0x")))
+	hash := hex.EncodeToString(keccak256(digits))
+
+	for i, c := range digits {
+		if c >= 'a' && c <= 'f' && hash[i] >= '8' {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(digits)
+}
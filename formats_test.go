@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+func TestValidChecksum(t *testing.T) {
+	for _, tc := range []struct {
+		addr  string
+		valid bool
+	}{
+		// Examples of EIP-55.
+		{addr: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", valid: true},
+		{addr: "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", valid: true},
+		{addr: "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", valid: true},
+		{addr: "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", valid: true},
+		{addr: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", valid: true},
+		{addr: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", valid: true},
+		{addr: "0x5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAed", valid: false},
+		{addr: "0xD1220a0cf47c7B9Be7A2E6BA89F429762e7b9aDb", valid: false},
+		// Malformed addresses are left to the pattern.
+		{addr: "0x5aAeb6053f", valid: true},
+		{addr: "5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAedxx", valid: true},
+	} {
+		if got := validChecksum(tc.addr); got != tc.valid {
+			t.Errorf("validChecksum(%s) = %v, want %v", tc.addr, got, tc.valid)
+		}
+	}
+
+	if got := checksumAddress("0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"); got != "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestChecksumValidator(t *testing.T) {
+	const (
+		schema  = `{"type":"array","items":{"type":"string","format":"address"}}`
+		valid   = `"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"`
+		invalid = `"0x5aAeb6053f3E94C9b9A09f33669435E7Ef1BeAed"`
+	)
+
+	checksums := NewChecksumValidator(&jsonrpc.JSONSchemaValidator{})
+	plain := &jsonrpc.JSONSchemaValidator{}
+
+	for _, v := range []jsonrpc.Validator{checksums, plain} {
+		if err := v.AddParamsSchema("eth_getBalance", []byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := v.AddResultSchema("eth_accounts", []byte(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		validator jsonrpc.Validator
+		params    string
+		result    string
+		err       bool
+	}{
+		{name: "valid checksum", validator: checksums, params: `[` + valid + `]`},
+		{name: "lower case", validator: checksums, params: `["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"]`},
+		{name: "invalid checksum", validator: checksums, params: `[` + invalid + `]`, err: true},
+		{name: "checksums not required", validator: plain, params: `[` + invalid + `]`},
+		{name: "result is not checked", validator: checksums, result: `[` + invalid + `]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+
+			if tc.params != "" {
+				err = tc.validator.ValidateParams("eth_getBalance", []byte(tc.params))
+			} else {
+				err = tc.validator.ValidateResult("eth_accounts", []byte(tc.result))
+			}
+
+			if (err != nil) != tc.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -3,16 +3,21 @@ module swagger-jsonrpc-v1
 go 1.17
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
 	github.com/go-chi/chi/v5 v5.0.7
+	github.com/gorilla/websocket v1.5.0
+	github.com/santhosh-tekuri/jsonschema/v2 v2.2.0
 	github.com/swaggest/jsonrpc v0.1.0
+	github.com/swaggest/jsonschema-go v0.3.19
+	github.com/swaggest/openapi-go v0.2.10
 	github.com/swaggest/swgui v1.4.2
 	github.com/swaggest/usecase v1.1.0
+	golang.org/x/crypto v0.9.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
-	github.com/santhosh-tekuri/jsonschema/v2 v2.2.0 // indirect
-	github.com/swaggest/jsonschema-go v0.3.19 // indirect
-	github.com/swaggest/openapi-go v0.2.10 // indirect
 	github.com/swaggest/refl v0.1.7 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
+	github.com/vearutop/statigz v1.1.5 // indirect
+	golang.org/x/sys v0.8.0 // indirect
 )
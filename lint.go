@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// methodName matches method names mentioned in text.
+var methodName = regexp.MustCompile(`\b[a-z0-9]+_[A-Za-z0-9]+\b`)
+
+// Lint checks catalog for problems that are not caught by loading it: methods without
+// summary, tags or samples, duplicate summaries and sample names, summaries that name
+// another method, write-like methods without access, and samples that do not match
+// method schemas.
+func (c *Catalog) Lint(v jsonrpc.Validator) []error {
+	var errs []error
+
+	methods := make(map[string]bool, len(c.Methods))
+	for _, m := range c.Methods {
+		methods[m.Name] = true
+	}
+
+	summaries := make(map[string]string, len(c.Methods))
+
+	for _, m := range c.Methods {
+		summary := strings.TrimSpace(m.Summary)
+
+		switch other, ok := summaries[strings.ToLower(summary)]; {
+		case summary == "":
+			errs = append(errs, fmt.Errorf("%s: missing summary", m.Name))
+		case ok:
+			errs = append(errs, fmt.Errorf("%s: summary duplicates %s: %q", m.Name, other, summary))
+		default:
+			summaries[strings.ToLower(summary)] = m.Name
+		}
+
+		for _, name := range methodName.FindAllString(summary, -1) {
+			if name != m.Name && methods[name] {
+				errs = append(errs, fmt.Errorf("%s: summary names method %s", m.Name, name))
+			}
+		}
+
+		if m.Access == "" && (writeLikeMethod.MatchString(m.Name) || methodAccess(m.Name) != AccessRead) {
+			errs = append(errs, fmt.Errorf("%s: missing access of a method that looks like it changes state", m.Name))
+		}
+
+		if len(m.Tags) == 0 {
+			errs = append(errs, fmt.Errorf("%s: missing tags", m.Name))
+		}
+
+		if len(m.Examples) == 0 {
+			errs = append(errs, fmt.Errorf("%s: missing sample", m.Name))
+		}
+
+		names := make(map[string]bool, len(m.Examples))
+
+		for i, ex := range m.Examples {
+			if names[ex.name(i)] {
+				errs = append(errs, fmt.Errorf("%s: duplicate sample name %q", m.Name, ex.name(i)))
+			}
+
+			names[ex.name(i)] = true
+		}
+	}
+
+	return append(errs, c.ValidateExamples(v)...)
+}
+
+// lint runs lint subcommand and returns exit code.
+func lint(fs *flag.FlagSet, args []string) int {
+	catalogFile := fs.String("catalog", env("CATALOG_FILE", "catalog.yaml"),
+		"YAML or JSON file with JSON-RPC methods catalog, env CATALOG_FILE")
+	extensions := fs.String("extensions", env("EXTENSION_FILES", "ethermint.yaml"),
+		"Comma separated catalog files that extend the catalog, env EXTENSION_FILES")
+	tendermintFile := fs.String("tendermint-catalog", env("TENDERMINT_CATALOG_FILE", "tendermint.yaml"),
+		"YAML or JSON file with Tendermint RPC methods catalog, empty to skip it, env TENDERMINT_CATALOG_FILE")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	code := 0
+
+	// Extensions refer to schemas of the catalog, so they are checked together with it.
+	catalog, err := loadExtended(*catalogFile, splitList(*extensions))
+	if err == nil {
+		err = lintCatalog(strings.Join(append([]string{*catalogFile}, splitList(*extensions)...), ", "), catalog)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		code = 1
+	}
+
+	if *tendermintFile != "" {
+		catalog, err := LoadCatalog(*tendermintFile)
+		if err == nil {
+			err = lintCatalog(*tendermintFile, catalog)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+
+			code = 1
+		}
+	}
+
+	return code
+}
+
+// loadExtended loads catalog with methods of extensions.
+func loadExtended(catalogFile string, extensionFiles []string) (*Catalog, error) {
+	catalog, err := LoadCatalog(catalogFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range extensionFiles {
+		ext, err := LoadCatalog(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := catalog.Extend(ext); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+
+	return catalog, nil
+}
+
+// lintCatalog prints problems of catalog loaded from files, it fails if there are any.
+func lintCatalog(files string, catalog *Catalog) error {
+	h, err := newHandler(catalog, nil, &jsonrpc.JSONSchemaValidator{})
+	if err != nil {
+		return fmt.Errorf("%s: %w", files, err)
+	}
+
+	errs := catalog.Lint(h.Validator)
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s: %d problems in %d methods", files, len(errs), len(catalog.Methods))
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+func TestCatalogLint(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		method Method
+		want   string
+	}{
+		{
+			name: "valid",
+			method: Method{
+				Name: "net_listening", Summary: "Tells whether node listens.", Tags: []string{"net"},
+				Examples: []Example{{}},
+			},
+		},
+		{
+			name:   "missing summary",
+			method: Method{Name: "net_listening", Tags: []string{"net"}, Examples: []Example{{}}},
+			want:   "net_listening: missing summary",
+		},
+		{
+			name: "duplicate summary",
+			method: Method{
+				Name: "net_listening", Summary: "Returns network id.", Tags: []string{"net"},
+				Examples: []Example{{}},
+			},
+			want: `net_listening: summary duplicates net_version: "Returns network id."`,
+		},
+		{
+			name:   "summary names method",
+			method: Method{Name: "net_listening", Summary: "Like net_version.", Tags: []string{"net"}, Examples: []Example{{}}},
+			want:   "net_listening: summary names method net_version",
+		},
+		{
+			name:   "missing tags",
+			method: Method{Name: "net_listening", Summary: "Tells whether node listens.", Examples: []Example{{}}},
+			want:   "net_listening: missing tags",
+		},
+		{
+			name:   "missing sample",
+			method: Method{Name: "net_listening", Summary: "Tells whether node listens.", Tags: []string{"net"}},
+			want:   "net_listening: missing sample",
+		},
+		{
+			name: "missing access",
+			method: Method{
+				Name: "net_setListening", Summary: "Starts or stops listening.", Tags: []string{"net"},
+				Examples: []Example{{}},
+			},
+			want: "net_setListening: missing access of a method that looks like it changes state",
+		},
+		{
+			name: "declared access",
+			method: Method{
+				Name: "net_setListening", Summary: "Starts or stops listening.", Tags: []string{"net"},
+				Examples: []Example{{}}, Access: AccessWrite,
+			},
+		},
+		{
+			name: "duplicate sample name",
+			method: Method{
+				Name: "net_listening", Summary: "Tells whether node listens.", Tags: []string{"net"},
+				Examples: []Example{{Name: "a"}, {Name: "a"}},
+			},
+			want: `net_listening: duplicate sample name "a"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Catalog{Methods: []Method{
+				{Name: "net_version", Summary: "Returns network id.", Tags: []string{"net"}, Examples: []Example{{}}},
+				tc.method,
+			}}
+
+			var got []string
+			for _, err := range c.Lint(&jsonrpc.JSONSchemaValidator{}) {
+				got = append(got, err.Error())
+			}
+
+			if strings.Join(got, "\n") != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestLintCatalogs keeps shipped catalogs, extensions and Tendermint catalog free of lint problems.
+func TestLintCatalogs(t *testing.T) {
+	extended, err := loadExtended("catalog.yaml", []string{"ethermint.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tendermint, err := LoadCatalog("tendermint.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]*Catalog{"catalog.yaml, ethermint.yaml": extended, "tendermint.yaml": tendermint} {
+		if err := lintCatalog(name, c); err != nil {
+			t.Error(err)
+		}
+	}
+}
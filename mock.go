@@ -0,0 +1,1202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+const (
+	mockGenesisTime = 1640995200 // 2022-01-01T00:00:00Z.
+	mockBlockTime   = 5
+	mockGasLimit    = 30000000
+)
+
+var (
+	mockBaseFee     = big.NewInt(1000000000)
+	mockPriorityFee = big.NewInt(1000000000)
+	mockBalance, _  = new(big.Int).SetString("10000000000000000000000", 10)
+	mockTokenSupply = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+
+	// mockKeys are well-known development keys, never use them on a real network.
+	mockKeys = []string{
+		"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+		"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+		"5de4111afa1a4b94908f83103eb1f1706367c2e68ca870fc3fb9a804cdab365a",
+	}
+
+	// emptyRoot is the root hash of an empty trie.
+	emptyRoot, _ = hex.DecodeString("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+)
+
+// MockNode is an in-memory Ethereum node with deterministic chain state.
+//
+// Every transaction is mined instantly into its own block with EIP-1559 base fee,
+// contracts behave as a minimal ERC-20 token, roots and hashes are Keccak-256 digests
+// of block data rather than Merkle-Patricia trie roots.
+type MockNode struct {
+	chainID *big.Int
+	signers []signer
+
+	mu           sync.Mutex
+	blocks       []*mockBlock
+	txs          map[string]mockTxRef
+	filters      map[Quantity]*mockFilter
+	lastFilterID uint64
+	subs         map[*mockSubscription]struct{}
+
+	// passwords and locked keep state of accounts added with personal_ methods,
+	// development accounts have no password.
+	passwords map[string]string
+	locked    map[string]bool
+}
+
+type mockTxRef struct {
+	block *mockBlock
+	index int
+}
+
+type mockAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	storage map[string][]byte
+
+	// accessed lists storage slots loaded or stored by execution when not nil.
+	accessed []string
+}
+
+// mockState maps hex addresses to accounts.
+type mockState map[string]*mockAccount
+
+type mockBlock struct {
+	number       uint64
+	timestamp    uint64
+	gasUsed      uint64
+	baseFee      *big.Int
+	hash         []byte
+	parentHash   []byte
+	stateRoot    []byte
+	txRoot       []byte
+	receiptsRoot []byte
+	bloom        []byte
+	txs          []*mockTx
+	receipts     []*mockReceipt
+	state        mockState
+}
+
+type mockTx struct {
+	typ        byte
+	chainID    *big.Int
+	nonce      uint64
+	gasPrice   *big.Int // Effective gas price of EIP-1559 transactions once mined.
+	tipCap     *big.Int
+	feeCap     *big.Int
+	gas        uint64
+	from       []byte
+	to         []byte
+	value      *big.Int
+	data       []byte
+	accessList AccessList
+	v, r, s    *big.Int
+	hash       []byte
+	raw        []byte
+}
+
+type mockReceipt struct {
+	status            uint64
+	gasUsed           uint64
+	cumulativeGasUsed uint64
+	contractAddress   []byte
+	logs              []mockLog
+	bloom             []byte
+}
+
+type mockLog struct {
+	address []byte
+	topics  [][]byte
+	data    []byte
+}
+
+const (
+	logFilter = iota
+	blockFilter
+	pendingFilter
+)
+
+type mockFilter struct {
+	kind   int
+	query  FilterObject
+	next   uint64
+	hashes []Hash
+}
+
+type mockSubscription struct {
+	kind  string
+	query FilterObject
+	ch    chan interface{}
+}
+
+// NewMockNode creates mock node with funded development accounts and a few blocks of history.
+func NewMockNode(chainID int64) *MockNode {
+	n := &MockNode{
+		chainID: big.NewInt(chainID),
+		txs:     map[string]mockTxRef{},
+		filters: map[Quantity]*mockFilter{},
+		subs:    map[*mockSubscription]struct{}{},
+
+		passwords: map[string]string{},
+		locked:    map[string]bool{},
+	}
+
+	genesis := &mockBlock{
+		timestamp:  mockGenesisTime,
+		baseFee:    mockBaseFee,
+		parentHash: make([]byte, 32),
+		state:      mockState{},
+	}
+
+	for _, k := range mockKeys {
+		s := newSigner(k)
+		n.signers = append(n.signers, s)
+		genesis.state.account(s.address).balance.Set(mockBalance)
+	}
+
+	if err := genesis.seal(); err != nil {
+		panic(err)
+	}
+
+	n.blocks = append(n.blocks, genesis)
+
+	alice, bob, carol := n.signers[0], n.signers[1], n.signers[2]
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	token, err := createAddress(alice.address, 1)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, tx := range []struct {
+		from  signer
+		to    []byte
+		value *big.Int
+		data  []byte
+	}{
+		{from: alice, to: bob.address, value: ether},
+		{from: alice, data: []byte{0x60, 0x80, 0x60, 0x40, 0x52, 0x34, 0x80, 0x15, 0x60, 0x0f, 0x57, 0x60, 0x00, 0x80, 0xfd}},
+		{from: alice, to: token, data: tokenTransferData(bob.address, new(big.Int).Mul(big.NewInt(1000), ether))},
+		{from: bob, to: carol.address, value: new(big.Int).Div(ether, big.NewInt(2))},
+	} {
+		if _, err := n.send(tx.from, TransactionArgs{}, tx.to, tx.value, tx.data); err != nil {
+			panic(err)
+		}
+	}
+
+	return n
+}
+
+// Call answers JSON-RPC method from in-memory chain state.
+func (n *MockNode) Call(_ context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	handler, ok := mockMethods[method]
+	if !ok {
+		return nil, methodNotFound(method)
+	}
+
+	var p mockParams
+
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, invalidParams("params must be an array: %v", err)
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	result, err := handler(n, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+func (n *MockNode) head() *mockBlock {
+	return n.blocks[len(n.blocks)-1]
+}
+
+func (n *MockNode) signer(address []byte) (signer, bool) {
+	for _, s := range n.signers {
+		if bytes.Equal(s.address, address) {
+			return s, true
+		}
+	}
+
+	return signer{}, false
+}
+
+// send signs and mines a transaction, missing fields are taken from args or defaults.
+func (n *MockNode) send(s signer, args TransactionArgs, to []byte, value *big.Int, data []byte) (*mockTx, error) {
+	state := n.head().state
+	baseFee := nextBaseFee(n.head())
+	tx := &mockTx{
+		typ:     DynamicFeeTxType,
+		chainID: n.chainID,
+		nonce:   state.get(s.address).nonce,
+		from:    s.address,
+		to:      to,
+		value:   value,
+		data:    data,
+	}
+
+	if tx.value == nil {
+		tx.value = new(big.Int)
+	}
+
+	if err := args.fees(tx); err != nil {
+		return nil, err
+	}
+
+	if tx.typ == DynamicFeeTxType {
+		if tx.tipCap == nil {
+			tx.tipCap = mockPriorityFee
+		}
+
+		if tx.feeCap == nil {
+			tx.feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tx.tipCap)
+		}
+	} else if tx.gasPrice == nil {
+		tx.gasPrice = new(big.Int).Add(baseFee, mockPriorityFee)
+	}
+
+	if args.Nonce != nil {
+		nonce, err := parseUint(*args.Nonce)
+		if err != nil {
+			return nil, invalidParams("invalid nonce: %v", err)
+		}
+
+		tx.nonce = nonce
+	}
+
+	if args.Gas != nil {
+		gas, err := parseUint(*args.Gas)
+		if err != nil {
+			return nil, invalidParams("invalid gas: %v", err)
+		}
+
+		tx.gas = gas
+	} else {
+		tx.gas = state.estimate(tx.from, tx.to, tx.value, tx.data) + accessListGas(tx.accessList)
+	}
+
+	accessList, err := tx.accessList.rlp()
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.typ == LegacyTxType {
+		fields := []interface{}{tx.nonce, tx.gasPrice, tx.gas, tx.to, tx.value, tx.data}
+
+		sigHash, err := rlpHash(append(fields, tx.chainID, uint64(0), uint64(0)))
+		if err != nil {
+			return nil, err
+		}
+
+		r, sig, recID := s.sign(sigHash)
+		tx.v = new(big.Int).Add(new(big.Int).Mul(tx.chainID, big.NewInt(2)), big.NewInt(35+int64(recID)))
+		tx.r, tx.s = r, sig
+
+		if tx.raw, err = rlpEncode(append(fields, tx.v, tx.r, tx.s)); err != nil {
+			return nil, err
+		}
+	} else {
+		var fields []interface{}
+
+		if tx.typ == DynamicFeeTxType {
+			fields = []interface{}{tx.chainID, tx.nonce, tx.tipCap, tx.feeCap, tx.gas, tx.to, tx.value, tx.data, accessList}
+		} else {
+			fields = []interface{}{tx.chainID, tx.nonce, tx.gasPrice, tx.gas, tx.to, tx.value, tx.data, accessList}
+		}
+
+		unsigned, err := rlpEncode(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		r, sig, recID := s.sign(keccak256([]byte{tx.typ}, unsigned))
+		tx.v = big.NewInt(int64(recID))
+		tx.r, tx.s = r, sig
+
+		signed, err := rlpEncode(append(fields, tx.v, tx.r, tx.s))
+		if err != nil {
+			return nil, err
+		}
+
+		tx.raw = append([]byte{tx.typ}, signed...)
+	}
+
+	tx.hash = keccak256(tx.raw)
+
+	return tx, n.mine(tx)
+}
+
+// decodeRawTx decodes signed legacy, EIP-2930 or EIP-1559 transaction and recovers its sender.
+func (n *MockNode) decodeRawTx(raw []byte) (*mockTx, error) {
+	if len(raw) == 0 {
+		return nil, invalidParams("empty transaction")
+	}
+
+	var (
+		tx      = &mockTx{raw: raw, hash: keccak256(raw)}
+		payload = raw
+		fields  []rlpItem
+		prefix  []byte
+		signing []byte
+		sigHash []byte
+		recID   uint64
+	)
+
+	if raw[0] < 0xc0 {
+		tx.typ = raw[0]
+		payload = raw[1:]
+	}
+
+	item, err := rlpDecode(payload)
+	if err != nil || !item.isList {
+		return nil, invalidParams("invalid transaction encoding: %v", err)
+	}
+
+	fields = item.list
+
+	unsigned := func(n int) ([]byte, error) {
+		list := make([]interface{}, 0, n)
+		for _, f := range fields[:n] {
+			list = append(list, rlpRaw(f.raw))
+		}
+
+		return rlpEncode(list)
+	}
+
+	// Positions of nonce, gas price, gas, to, value and data fields.
+	var pos [6]int
+
+	switch {
+	case tx.typ == 0 && len(fields) == 9:
+		pos = [6]int{0, 1, 2, 3, 4, 5}
+	case tx.typ == 1 && len(fields) == 11:
+		pos = [6]int{1, 2, 3, 4, 5, 6}
+	case tx.typ == 2 && len(fields) == 12:
+		// Fee cap is read as gas price, effective gas price is set when mined.
+		pos = [6]int{1, 3, 4, 5, 6, 7}
+	default:
+		return nil, invalidParams("unsupported transaction type %d", tx.typ)
+	}
+
+	nums := make([]*big.Int, 0, 3)
+
+	for _, f := range fields[len(fields)-3:] {
+		v, err := f.big()
+		if err != nil {
+			return nil, invalidParams("invalid signature: %v", err)
+		}
+
+		nums = append(nums, v)
+	}
+
+	tx.v, tx.r, tx.s = nums[0], nums[1], nums[2]
+
+	if tx.typ == 0 {
+		switch {
+		case tx.v.Cmp(big.NewInt(35)) >= 0:
+			tx.chainID = new(big.Int).Rsh(new(big.Int).Sub(tx.v, big.NewInt(35)), 1)
+			recID = new(big.Int).Sub(tx.v, big.NewInt(35)).Uint64() % 2
+			signing, err = legacySigningPayload(fields, tx.chainID)
+		case tx.v.Uint64() == 27 || tx.v.Uint64() == 28:
+			recID = tx.v.Uint64() - 27
+			signing, err = unsigned(6)
+		default:
+			return nil, invalidParams("invalid signature v value")
+		}
+	} else {
+		if tx.chainID, err = fields[0].big(); err != nil {
+			return nil, invalidParams("invalid chain id: %v", err)
+		}
+
+		recID = tx.v.Uint64()
+		prefix = []byte{tx.typ}
+		signing, err = unsigned(len(fields) - 3)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sigHash = keccak256(prefix, signing)
+
+	if tx.chainID != nil && tx.chainID.Cmp(n.chainID) != 0 {
+		return nil, execError("invalid chain id %s, expected %s", tx.chainID, n.chainID)
+	}
+
+	if tx.nonce, err = fields[pos[0]].uint(); err != nil {
+		return nil, invalidParams("invalid nonce: %v", err)
+	}
+
+	if tx.gasPrice, err = fields[pos[1]].big(); err != nil {
+		return nil, invalidParams("invalid gas price: %v", err)
+	}
+
+	if tx.gas, err = fields[pos[2]].uint(); err != nil {
+		return nil, invalidParams("invalid gas: %v", err)
+	}
+
+	if to := fields[pos[3]].str; len(to) == 20 {
+		tx.to = to
+	} else if len(to) != 0 || fields[pos[3]].isList {
+		return nil, invalidParams("invalid recipient")
+	}
+
+	if tx.value, err = fields[pos[4]].big(); err != nil {
+		return nil, invalidParams("invalid value: %v", err)
+	}
+
+	tx.data = fields[pos[5]].str
+
+	if tx.typ != LegacyTxType {
+		if tx.accessList, err = decodeAccessList(fields[len(fields)-4]); err != nil {
+			return nil, err
+		}
+	}
+
+	if tx.typ == DynamicFeeTxType {
+		tx.feeCap = tx.gasPrice
+
+		if tx.tipCap, err = fields[2].big(); err != nil {
+			return nil, invalidParams("invalid max priority fee: %v", err)
+		}
+	}
+
+	if tx.from, err = recoverAddress(sigHash, tx.r, tx.s, byte(recID)); err != nil {
+		return nil, invalidParams("invalid signature: %v", err)
+	}
+
+	return tx, nil
+}
+
+// rlp returns access list items for RLP encoding.
+func (l AccessList) rlp() ([]interface{}, error) {
+	items := make([]interface{}, 0, len(l))
+
+	for _, t := range l {
+		addr, err := parseAddress(t.Address)
+		if err != nil {
+			return nil, invalidParams("invalid access list: %v", err)
+		}
+
+		keys := make([]interface{}, 0, len(t.StorageKeys))
+
+		for _, k := range t.StorageKeys {
+			key, err := parseHex(string(k))
+			if err != nil || len(key) != 32 {
+				return nil, invalidParams("invalid access list storage key %q", k)
+			}
+
+			keys = append(keys, key)
+		}
+
+		items = append(items, []interface{}{addr, keys})
+	}
+
+	return items, nil
+}
+
+// decodeAccessList decodes RLP list of access list items.
+func decodeAccessList(item rlpItem) (AccessList, error) {
+	if !item.isList {
+		return nil, invalidParams("invalid access list")
+	}
+
+	l := make(AccessList, 0, len(item.list))
+
+	for _, t := range item.list {
+		if !t.isList || len(t.list) != 2 || t.list[0].isList || len(t.list[0].str) != 20 || !t.list[1].isList {
+			return nil, invalidParams("invalid access list")
+		}
+
+		keys := make([]Hash, 0, len(t.list[1].list))
+
+		for _, k := range t.list[1].list {
+			if k.isList || len(k.str) != 32 {
+				return nil, invalidParams("invalid access list storage key")
+			}
+
+			keys = append(keys, Hash(hexBytes(k.str)))
+		}
+
+		l = append(l, AccessTuple{Address: Address(hexBytes(t.list[0].str)), StorageKeys: keys})
+	}
+
+	return l, nil
+}
+
+// legacySigningPayload encodes EIP-155 signing payload of a legacy transaction.
+func legacySigningPayload(fields []rlpItem, chainID *big.Int) ([]byte, error) {
+	list := make([]interface{}, 0, 9)
+	for _, f := range fields[:6] {
+		list = append(list, rlpRaw(f.raw))
+	}
+
+	return rlpEncode(append(list, chainID, uint64(0), uint64(0)))
+}
+
+// mine executes transaction in a new block.
+func (n *MockNode) mine(tx *mockTx) error {
+	if _, exists := n.txs[hexBytes(tx.hash)]; exists {
+		return execError("already known")
+	}
+
+	parent := n.head()
+	state := parent.state.copy()
+	baseFee := nextBaseFee(parent)
+
+	if tx.feeCap != nil {
+		if tx.tipCap.Cmp(tx.feeCap) > 0 {
+			return execError("max priority fee per gas higher than max fee per gas")
+		}
+
+		tx.gasPrice = new(big.Int).Add(baseFee, tx.tipCap)
+		if tx.gasPrice.Cmp(tx.feeCap) > 0 {
+			tx.gasPrice = tx.feeCap
+		}
+	}
+
+	if tx.gasPrice.Cmp(baseFee) < 0 {
+		return execError("max fee per gas less than block base fee: maxFeePerGas: %s, baseFee: %s", tx.gasPrice, baseFee)
+	}
+
+	r, err := state.execute(tx)
+	if err != nil {
+		return err
+	}
+
+	r.cumulativeGasUsed = r.gasUsed
+
+	b := &mockBlock{
+		number:     parent.number + 1,
+		timestamp:  parent.timestamp + mockBlockTime,
+		gasUsed:    r.gasUsed,
+		baseFee:    baseFee,
+		parentHash: parent.hash,
+		txs:        []*mockTx{tx},
+		receipts:   []*mockReceipt{r},
+		state:      state,
+	}
+	if err := b.seal(); err != nil {
+		return err
+	}
+
+	n.blocks = append(n.blocks, b)
+	n.txs[hexBytes(tx.hash)] = mockTxRef{block: b}
+
+	for _, f := range n.filters {
+		if f.kind == pendingFilter {
+			f.hashes = append(f.hashes, Hash(hexBytes(tx.hash)))
+		}
+	}
+
+	for s := range n.subs {
+		switch s.kind {
+		case "newHeads":
+			s.send(b.render(false))
+		case "newPendingTransactions":
+			s.send(Hash(hexBytes(tx.hash)))
+		case "logs":
+			for _, l := range b.logs() {
+				if s.query.matches(l) {
+					s.send(l)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Subscribe starts newHeads, logs or newPendingTransactions subscription.
+// Mock subscriptions are never closed by the node, so closed is not called.
+func (n *MockNode) Subscribe(
+	ctx context.Context, params json.RawMessage, notify func(json.RawMessage), closed func(error),
+) error {
+	var p mockParams
+
+	if err := json.Unmarshal(params, &p); err != nil {
+		return invalidParams("params must be an array: %v", err)
+	}
+
+	s := &mockSubscription{ch: make(chan interface{}, 256)}
+
+	if err := p.get(0, &s.kind); err != nil {
+		return err
+	}
+
+	switch s.kind {
+	case "newHeads", "newPendingTransactions":
+	case "logs":
+		if _, err := p.optional(1, &s.query); err != nil {
+			return err
+		}
+
+		if s.query.BlockHash != nil || s.query.FromBlock != nil || s.query.ToBlock != nil {
+			return invalidParams("logs subscription filter accepts only address and topics")
+		}
+	default:
+		return invalidParams("unsupported subscription type %q", s.kind)
+	}
+
+	n.mu.Lock()
+	n.subs[s] = struct{}{}
+	n.mu.Unlock()
+
+	go func() {
+		defer func() {
+			n.mu.Lock()
+			delete(n.subs, s)
+			n.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v := <-s.ch:
+				if data, err := json.Marshal(v); err == nil {
+					notify(data)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// send queues notification, it is dropped if subscriber lags too much.
+func (s *mockSubscription) send(v interface{}) {
+	select {
+	case s.ch <- v:
+	default:
+	}
+}
+
+// seal computes block bloom, roots and hash.
+func (b *mockBlock) seal() error {
+	var err error
+
+	b.bloom = make([]byte, 256)
+	b.txRoot = emptyRoot
+	b.receiptsRoot = emptyRoot
+
+	if len(b.txs) > 0 {
+		var txs, receipts []interface{}
+
+		for i, tx := range b.txs {
+			r := b.receipts[i]
+			txs = append(txs, tx.hash)
+			receipts = append(receipts, []interface{}{r.status, r.cumulativeGasUsed, r.bloom})
+
+			for j := range b.bloom {
+				b.bloom[j] |= r.bloom[j]
+			}
+		}
+
+		if b.txRoot, err = rlpHash(txs); err != nil {
+			return err
+		}
+
+		if b.receiptsRoot, err = rlpHash(receipts); err != nil {
+			return err
+		}
+	}
+
+	if b.stateRoot, err = b.state.root(); err != nil {
+		return err
+	}
+
+	b.hash, err = rlpHash([]interface{}{
+		b.parentHash, b.stateRoot, b.txRoot, b.receiptsRoot, b.bloom,
+		b.number, uint64(mockGasLimit), b.gasUsed, b.timestamp, b.baseFee,
+	})
+
+	return err
+}
+
+// nextBaseFee returns base fee of the block after parent, see EIP-1559.
+func nextBaseFee(parent *mockBlock) *big.Int {
+	target := new(big.Int).SetUint64(mockGasLimit / 2)
+	used := new(big.Int).SetUint64(parent.gasUsed)
+	fee := new(big.Int).Set(parent.baseFee)
+
+	delta := new(big.Int).Sub(used, target)
+	delta.Mul(delta, parent.baseFee).Quo(delta, target).Quo(delta, big.NewInt(8))
+
+	if used.Cmp(target) > 0 && delta.Sign() == 0 {
+		delta.SetInt64(1)
+	}
+
+	return fee.Add(fee, delta)
+}
+
+// execute applies transaction to state, failed execution reverts everything but fees and nonce.
+func (s mockState) execute(tx *mockTx) (*mockReceipt, error) {
+	sender := s.account(tx.from)
+
+	switch {
+	case tx.nonce < sender.nonce:
+		return nil, execError("nonce too low")
+	case tx.nonce > sender.nonce:
+		return nil, execError("nonce too high")
+	case tx.gas > mockGasLimit:
+		return nil, execError("exceeds block gas limit")
+	case tx.gas < intrinsicGas(tx.data, tx.to == nil)+accessListGas(tx.accessList):
+		return nil, execError("intrinsic gas too low")
+	}
+
+	price := tx.gasPrice
+	if tx.feeCap != nil {
+		price = tx.feeCap
+	}
+
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.gas), price)
+	if cost.Add(cost, tx.value).Cmp(sender.balance) > 0 {
+		return nil, execError("insufficient funds for gas * price + value")
+	}
+
+	sender.nonce++
+
+	r := &mockReceipt{status: 1, bloom: make([]byte, 256)}
+	exec := s.copy()
+
+	gas, logs, created, _, err := exec.run(tx.from, tx.to, tx.value, tx.data, tx.nonce)
+	gas += intrinsicGas(tx.data, tx.to == nil) + accessListGas(tx.accessList)
+
+	if err != nil || gas > tx.gas {
+		r.status = 0
+
+		if gas > tx.gas {
+			gas = tx.gas
+		}
+	} else {
+		for addr, acc := range exec {
+			s[addr] = acc
+		}
+
+		r.logs = logs
+		r.contractAddress = created
+	}
+
+	r.gasUsed = gas
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gas), tx.gasPrice)
+	s.account(tx.from).balance.Sub(s.account(tx.from).balance, fee)
+
+	for _, l := range r.logs {
+		bloomAdd(r.bloom, l.address)
+
+		for _, t := range l.topics {
+			bloomAdd(r.bloom, t)
+		}
+	}
+
+	return r, nil
+}
+
+// intrinsicGas returns gas charged before execution.
+func intrinsicGas(data []byte, create bool) uint64 {
+	gas := uint64(21000)
+	if create {
+		gas += 32000
+	}
+
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+
+	return gas
+}
+
+// accessListGas returns gas charged for access list, see EIP-2930.
+func accessListGas(l AccessList) uint64 {
+	gas := uint64(0)
+
+	for _, t := range l {
+		gas += 2400 + 1900*uint64(len(t.StorageKeys))
+	}
+
+	return gas
+}
+
+// estimate returns gas needed for a call.
+func (s mockState) estimate(from, to []byte, value *big.Int, data []byte) uint64 {
+	gas, _, _, _, _ := s.copy().run(from, to, value, data, s.get(from).nonce)
+
+	return gas + intrinsicGas(data, to == nil)
+}
+
+// run transfers value and executes contract, it returns gas used on top of intrinsic gas.
+func (s mockState) run(from, to []byte, value *big.Int, data []byte, nonce uint64) (
+	gas uint64, logs []mockLog, created []byte, ret []byte, err error,
+) {
+	sender := s.account(from)
+	if sender.balance.Cmp(value) < 0 {
+		return 0, nil, nil, nil, execError("insufficient funds for transfer")
+	}
+
+	sender.balance.Sub(sender.balance, value)
+
+	if to == nil {
+		if created, err = createAddress(from, nonce); err != nil {
+			return 0, nil, nil, nil, err
+		}
+
+		contract := s.account(created)
+		contract.balance.Add(contract.balance, value)
+		contract.code = data
+		contract.storage[hex.EncodeToString(tokenBalanceSlot(from))] = word(mockTokenSupply)
+		contract.storage[hex.EncodeToString(tokenSupplySlot)] = word(mockTokenSupply)
+
+		return 200*uint64(len(data)) + 2*20000, nil, created, nil, nil
+	}
+
+	recipient := s.account(to)
+	recipient.balance.Add(recipient.balance, value)
+
+	if len(recipient.code) == 0 {
+		return 0, nil, nil, nil, nil
+	}
+
+	return recipient.token(from, to, data)
+}
+
+// token executes call of a minimal ERC-20 contract.
+func (a *mockAccount) token(from, contract, data []byte) (
+	gas uint64, logs []mockLog, created []byte, ret []byte, err error,
+) {
+	if len(data) < 4 {
+		return 2600, nil, nil, nil, nil
+	}
+
+	args := data[4:]
+	arg := func(i int) []byte {
+		if len(args) < 32*(i+1) {
+			return nil
+		}
+
+		return args[32*i : 32*(i+1)]
+	}
+
+	switch hex.EncodeToString(data[:4]) {
+	case "a9059cbb": // transfer(address,uint256)
+		to, amount := arg(0), arg(1)
+		if to == nil || amount == nil {
+			return 2600, nil, nil, nil, execError("execution reverted")
+		}
+
+		fromSlot := hex.EncodeToString(tokenBalanceSlot(from))
+		toSlot := hex.EncodeToString(tokenBalanceSlot(to[12:]))
+		balance := new(big.Int).SetBytes(a.load(fromSlot))
+		value := new(big.Int).SetBytes(amount)
+
+		if balance.Cmp(value) < 0 {
+			return 2600, nil, nil, nil, execError("execution reverted")
+		}
+
+		a.store(fromSlot, word(balance.Sub(balance, value)))
+		a.store(toSlot, word(new(big.Int).Add(new(big.Int).SetBytes(a.load(toSlot)), value)))
+
+		return 29000, []mockLog{{
+			address: contract,
+			topics:  [][]byte{tokenTransferTopic, leftPad(from, 32), to},
+			data:    amount,
+		}}, nil, word(big.NewInt(1)), nil
+	case "70a08231": // balanceOf(address)
+		if arg(0) == nil {
+			return 2600, nil, nil, nil, execError("execution reverted")
+		}
+
+		return 2600, nil, nil, leftPad(a.load(hex.EncodeToString(tokenBalanceSlot(arg(0)[12:]))), 32), nil
+	case "18160ddd": // totalSupply()
+		return 2600, nil, nil, leftPad(a.load(hex.EncodeToString(tokenSupplySlot)), 32), nil
+	case "313ce567": // decimals()
+		return 2600, nil, nil, word(big.NewInt(18)), nil
+	case "06fdde03": // name()
+		return 2600, nil, nil, abiString("Mock Token"), nil
+	case "95d89b41": // symbol()
+		return 2600, nil, nil, abiString("MOCK"), nil
+	default:
+		return 2600, nil, nil, nil, nil
+	}
+}
+
+// load returns value of a storage slot.
+func (a *mockAccount) load(slot string) []byte {
+	a.access(slot)
+
+	return a.storage[slot]
+}
+
+// store sets value of a storage slot.
+func (a *mockAccount) store(slot string, value []byte) {
+	a.access(slot)
+	a.storage[slot] = value
+}
+
+// access records storage slot if access is tracked.
+func (a *mockAccount) access(slot string) {
+	if a.accessed == nil {
+		return
+	}
+
+	for _, s := range a.accessed {
+		if s == slot {
+			return
+		}
+	}
+
+	a.accessed = append(a.accessed, slot)
+}
+
+var (
+	tokenTransferTopic = keccak256([]byte("Transfer(address,address,uint256)"))
+	tokenSupplySlot    = word(big.NewInt(2))
+)
+
+// tokenBalanceSlot returns storage slot of balance in the mapping at slot 0.
+func tokenBalanceSlot(address []byte) []byte {
+	return keccak256(leftPad(address, 32), make([]byte, 32))
+}
+
+// tokenTransferData encodes transfer(address,uint256) call.
+func tokenTransferData(to []byte, amount *big.Int) []byte {
+	data, _ := hex.DecodeString("a9059cbb")
+
+	return append(append(data, leftPad(to, 32)...), word(amount)...)
+}
+
+func abiString(s string) []byte {
+	data := append(word(big.NewInt(32)), word(big.NewInt(int64(len(s))))...)
+
+	return append(data, rightPad([]byte(s), (len(s)+31)/32*32)...)
+}
+
+// createAddress returns address of a contract created by sender with nonce.
+func createAddress(sender []byte, nonce uint64) ([]byte, error) {
+	h, err := rlpHash([]interface{}{sender, nonce})
+	if err != nil {
+		return nil, err
+	}
+
+	return h[12:], nil
+}
+
+func bloomAdd(bloom, data []byte) {
+	h := keccak256(data)
+
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(h[i])<<8 | uint(h[i+1])) & 2047
+		bloom[255-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (s mockState) account(address []byte) *mockAccount {
+	key := hex.EncodeToString(address)
+
+	acc, ok := s[key]
+	if !ok {
+		acc = &mockAccount{balance: new(big.Int), storage: map[string][]byte{}}
+		s[key] = acc
+	}
+
+	return acc
+}
+
+// get returns account without creating it.
+func (s mockState) get(address []byte) mockAccount {
+	if acc, ok := s[hex.EncodeToString(address)]; ok {
+		return *acc
+	}
+
+	return mockAccount{balance: new(big.Int), storage: map[string][]byte{}}
+}
+
+func (s mockState) copy() mockState {
+	c := make(mockState, len(s))
+
+	for addr, acc := range s {
+		storage := make(map[string][]byte, len(acc.storage))
+		for k, v := range acc.storage {
+			storage[k] = v
+		}
+
+		c[addr] = &mockAccount{
+			balance: new(big.Int).Set(acc.balance),
+			nonce:   acc.nonce,
+			code:    acc.code,
+			storage: storage,
+		}
+	}
+
+	return c
+}
+
+func (s mockState) root() ([]byte, error) {
+	addrs := make([]string, 0, len(s))
+	for addr := range s {
+		addrs = append(addrs, addr)
+	}
+
+	sort.Strings(addrs)
+
+	accounts := make([]interface{}, 0, len(addrs))
+
+	for _, addr := range addrs {
+		acc := s[addr]
+
+		storageRoot, err := acc.storageRoot()
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, []interface{}{addr, acc.nonce, acc.balance, keccak256(acc.code), storageRoot})
+	}
+
+	return rlpHash(accounts)
+}
+
+// storageRoot returns digest of account storage, root of an empty trie if there is no storage.
+func (a *mockAccount) storageRoot() ([]byte, error) {
+	if len(a.storage) == 0 {
+		return emptyRoot, nil
+	}
+
+	slots := make([]string, 0, len(a.storage))
+
+	for k := range a.storage {
+		slots = append(slots, k)
+	}
+
+	sort.Strings(slots)
+
+	storage := make([]interface{}, 0, 2*len(slots))
+	for _, k := range slots {
+		storage = append(storage, k, a.storage[k])
+	}
+
+	return rlpHash(storage)
+}
+
+func word(v *big.Int) []byte {
+	return leftPad(v.Bytes(), 32)
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func rightPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	return append(append([]byte{}, b...), make([]byte, size-len(b))...)
+}
+
+func hexBytes(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func hexUint(u uint64) Quantity {
+	return Quantity("0x" + strconv.FormatUint(u, 16))
+}
+
+func hexBig(i *big.Int) Quantity {
+	return Quantity("0x" + i.Text(16))
+}
+
+// parseHex decodes 0x prefixed hex string.
+func parseHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, fmt.Errorf("hex string without 0x prefix: %q", s)
+	}
+
+	return hex.DecodeString(s[2:])
+}
+
+// parseQuantity decodes hex encoded unsigned integer.
+func parseQuantity(q Quantity) (*big.Int, error) {
+	s := string(q)
+	if !strings.HasPrefix(s, "0x") || len(s) < 3 {
+		return nil, fmt.Errorf("invalid quantity: %q", s)
+	}
+
+	v, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity: %q", s)
+	}
+
+	return v, nil
+}
+
+func parseUint(q Quantity) (uint64, error) {
+	v, err := parseQuantity(q)
+	if err != nil {
+		return 0, err
+	}
+
+	if !v.IsUint64() {
+		return 0, fmt.Errorf("quantity overflows 64 bits: %q", q)
+	}
+
+	return v.Uint64(), nil
+}
+
+func parseAddress(a Address) ([]byte, error) {
+	b, err := parseHex(string(a))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) != 20 {
+		return nil, fmt.Errorf("address must be 20 bytes: %q", a)
+	}
+
+	return b, nil
+}
+
+func invalidParams(format string, args ...interface{}) error {
+	return UpstreamError{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+func methodNotFound(method string) error {
+	return UpstreamError{
+		Code:    jsonrpc.CodeMethodNotFound,
+		Message: fmt.Sprintf("the method %s does not exist/is not available", method),
+	}
+}
+
+func execError(format string, args ...interface{}) error {
+	return UpstreamError{Code: -32000, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,1367 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// mockMethods maps method names to mock node handlers, handlers are called with the node locked.
+var mockMethods = map[string]func(n *MockNode, p mockParams) (interface{}, error){
+	"web3_clientVersion":                      (*MockNode).clientVersion,
+	"web3_sha3":                               (*MockNode).sha3,
+	"net_version":                             (*MockNode).netVersion,
+	"net_peerCount":                           constant(Quantity("0x0")),
+	"net_listening":                           constant(true),
+	"eth_protocolVersion":                     constant(Quantity("0x41")),
+	"eth_syncing":                             constant(Syncing{}),
+	"eth_chainId":                             (*MockNode).getChainID,
+	"eth_gasPrice":                            (*MockNode).gasPrice,
+	"eth_maxPriorityFeePerGas":                constant(hexBig(mockPriorityFee)),
+	"eth_blobBaseFee":                         constant(Quantity("0x1")),
+	"eth_feeHistory":                          (*MockNode).feeHistory,
+	"eth_accounts":                            (*MockNode).accounts,
+	"eth_blockNumber":                         (*MockNode).blockNumber,
+	"eth_getBalance":                          (*MockNode).getBalance,
+	"eth_getStorageAt":                        (*MockNode).getStorageAt,
+	"eth_getTransactionCount":                 (*MockNode).getTransactionCount,
+	"eth_getBlockTransactionCountByHash":      (*MockNode).getBlockTransactionCountByHash,
+	"eth_getBlockTransactionCountByNumber":    (*MockNode).getBlockTransactionCountByNumber,
+	"eth_getUncleCountByBlockHash":            (*MockNode).getUncleCountByBlockHash,
+	"eth_getUncleCountByBlockNumber":          (*MockNode).getUncleCountByBlockNumber,
+	"eth_getCode":                             (*MockNode).getCode,
+	"eth_getProof":                            (*MockNode).getProof,
+	"eth_sign":                                (*MockNode).sign,
+	"eth_sendTransaction":                     (*MockNode).sendTransaction,
+	"eth_sendRawTransaction":                  (*MockNode).sendRawTransaction,
+	"eth_getBlockByHash":                      (*MockNode).getBlockByHash,
+	"eth_getBlockByNumber":                    (*MockNode).getBlockByNumber,
+	"eth_getTransactionByHash":                (*MockNode).getTransactionByHash,
+	"eth_getTransactionByBlockHashAndIndex":   (*MockNode).getTransactionByBlockHashAndIndex,
+	"eth_getTransactionByBlockNumberAndIndex": (*MockNode).getTransactionByBlockNumberAndIndex,
+	"eth_getTransactionReceipt":               (*MockNode).getTransactionReceipt,
+	"eth_getBlockReceipts":                    (*MockNode).getBlockReceipts,
+	"eth_getUncleByBlockHashAndIndex":         constant(nil),
+	"eth_getUncleByBlockNumberAndIndex":       constant(nil),
+	"eth_newFilter":                           (*MockNode).newFilter,
+	"eth_newBlockFilter":                      (*MockNode).newBlockFilter,
+	"eth_newPendingTransactionFilter":         (*MockNode).newPendingTransactionFilter,
+	"eth_uninstallFilter":                     (*MockNode).uninstallFilter,
+	"eth_getFilterChanges":                    (*MockNode).getFilterChanges,
+	"eth_getFilterLogs":                       (*MockNode).getFilterLogs,
+	"eth_getLogs":                             (*MockNode).getLogs,
+	"eth_call":                                (*MockNode).call,
+	"eth_estimateGas":                         (*MockNode).estimateGas,
+	"eth_createAccessList":                    (*MockNode).createAccessList,
+	"debug_traceTransaction":                  (*MockNode).traceTransaction,
+	"debug_traceBlockByNumber":                (*MockNode).traceBlockByNumber,
+	"debug_traceBlockByHash":                  (*MockNode).traceBlockByHash,
+	"debug_traceCall":                         (*MockNode).traceCall,
+	"txpool_content":                          (*MockNode).txPoolContent,
+	"txpool_inspect":                          (*MockNode).txPoolInspect,
+	"txpool_status":                           constant(TxPoolStatus{Pending: "0x0", Queued: "0x0"}),
+	"personal_listAccounts":                   (*MockNode).accounts,
+	"personal_newAccount":                     (*MockNode).newAccount,
+	"personal_importRawKey":                   (*MockNode).importRawKey,
+	"personal_unlockAccount":                  (*MockNode).unlockAccount,
+	"personal_lockAccount":                    (*MockNode).lockAccount,
+	"personal_sendTransaction":                (*MockNode).personalSendTransaction,
+	"personal_sign":                           (*MockNode).personalSign,
+	"personal_ecRecover":                      (*MockNode).ecRecover,
+	"cronos_getTransactionReceiptsByBlock":    (*MockNode).getBlockReceipts,
+	"miner_setEtherbase":                      (*MockNode).setEtherbase,
+	"miner_setGasPrice":                       (*MockNode).setGasPrice,
+	"eth_subscribe":                           notificationsNotSupported,
+	"eth_unsubscribe":                         notificationsNotSupported,
+}
+
+// mockParams are positional params of a call.
+type mockParams []json.RawMessage
+
+// get decodes a required param.
+func (p mockParams) get(i int, v interface{}) error {
+	if i >= len(p) {
+		return invalidParams("missing value for required argument %d", i)
+	}
+
+	if err := json.Unmarshal(p[i], v); err != nil {
+		return invalidParams("invalid argument %d: %v", i, err)
+	}
+
+	return nil
+}
+
+// optional decodes an optional param, it returns false if param is missing or null.
+func (p mockParams) optional(i int, v interface{}) (bool, error) {
+	if i >= len(p) || string(p[i]) == "null" {
+		return false, nil
+	}
+
+	return true, p.get(i, v)
+}
+
+func (p mockParams) address(i int) ([]byte, error) {
+	var a Address
+
+	if err := p.get(i, &a); err != nil {
+		return nil, err
+	}
+
+	b, err := parseAddress(a)
+	if err != nil {
+		return nil, invalidParams("invalid argument %d: %v", i, err)
+	}
+
+	return b, nil
+}
+
+func (p mockParams) hash(i int) ([]byte, error) {
+	var h Hash
+
+	if err := p.get(i, &h); err != nil {
+		return nil, err
+	}
+
+	b, err := parseHex(string(h))
+	if err != nil || len(b) != 32 {
+		return nil, invalidParams("invalid argument %d: hash must be 32 hex encoded bytes", i)
+	}
+
+	return b, nil
+}
+
+func (p mockParams) uint(i int) (uint64, error) {
+	var q Quantity
+
+	if err := p.get(i, &q); err != nil {
+		return 0, err
+	}
+
+	v, err := parseUint(q)
+	if err != nil {
+		return 0, invalidParams("invalid argument %d: %v", i, err)
+	}
+
+	return v, nil
+}
+
+func notificationsNotSupported(*MockNode, mockParams) (interface{}, error) {
+	return nil, UpstreamError{Code: jsonrpc.CodeMethodNotFound, Message: "notifications not supported, use WebSocket endpoint"}
+}
+
+func constant(v interface{}) func(n *MockNode, p mockParams) (interface{}, error) {
+	return func(*MockNode, mockParams) (interface{}, error) {
+		return v, nil
+	}
+}
+
+// blockByTag returns block by number or tag, nil if there is no such block yet.
+func (n *MockNode) blockByTag(tag BlockTag) (*mockBlock, error) {
+	switch tag {
+	case "latest", "pending", "safe", "finalized":
+		return n.head(), nil
+	case "earliest":
+		return n.blocks[0], nil
+	}
+
+	num, err := parseQuantity(Quantity(tag))
+	if err != nil {
+		return nil, invalidParams("invalid block number or tag: %q", tag)
+	}
+
+	if !num.IsUint64() || num.Uint64() >= uint64(len(n.blocks)) {
+		return nil, nil
+	}
+
+	return n.blocks[num.Uint64()], nil
+}
+
+func (n *MockNode) blockByHash(hash []byte) *mockBlock {
+	for _, b := range n.blocks {
+		if string(b.hash) == string(hash) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// paramBlock returns block of a param, "latest" if param is optional and missing.
+func (n *MockNode) paramBlock(p mockParams, i int, required bool) (*mockBlock, error) {
+	tag := BlockTag("latest")
+
+	if required {
+		if err := p.get(i, &tag); err != nil {
+			return nil, err
+		}
+	} else if _, err := p.optional(i, &tag); err != nil {
+		return nil, err
+	}
+
+	return n.blockByTag(tag)
+}
+
+// paramState returns state at block of a param.
+func (n *MockNode) paramState(p mockParams, i int, required bool) (mockState, error) {
+	b, err := n.paramBlock(p, i, required)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, execError("header not found")
+	}
+
+	return b.state, nil
+}
+
+func (n *MockNode) clientVersion(mockParams) (interface{}, error) {
+	return "MockNode/v1.0.0/swagger-jsonrpc", nil
+}
+
+func (n *MockNode) sha3(p mockParams) (interface{}, error) {
+	var d Data
+
+	if err := p.get(0, &d); err != nil {
+		return nil, err
+	}
+
+	b, err := parseHex(string(d))
+	if err != nil {
+		return nil, invalidParams("invalid argument 0: %v", err)
+	}
+
+	return Data(hexBytes(keccak256(b))), nil
+}
+
+func (n *MockNode) netVersion(mockParams) (interface{}, error) {
+	return n.chainID.String(), nil
+}
+
+func (n *MockNode) getChainID(mockParams) (interface{}, error) {
+	return hexBig(n.chainID), nil
+}
+
+func (n *MockNode) gasPrice(mockParams) (interface{}, error) {
+	return hexBig(new(big.Int).Add(nextBaseFee(n.head()), mockPriorityFee)), nil
+}
+
+// setEtherbase accepts etherbase without effect, blocks of the mock node have no proposer.
+func (n *MockNode) setEtherbase(p mockParams) (interface{}, error) {
+	if _, err := p.address(0); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// setGasPrice accepts minimum gas price without effect, gas price follows the base fee.
+func (n *MockNode) setGasPrice(p mockParams) (interface{}, error) {
+	if _, err := p.uint(0); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// feeHistory reports the same priority fee for every percentile, as blocks have a single transaction.
+func (n *MockNode) feeHistory(p mockParams) (interface{}, error) {
+	count, err := p.uint(0)
+	if err != nil {
+		return nil, err
+	}
+
+	newest, err := n.paramBlock(p, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if newest == nil {
+		return nil, execError("request beyond head block")
+	}
+
+	var percentiles []float64
+
+	if _, err := p.optional(2, &percentiles); err != nil {
+		return nil, err
+	}
+
+	for i, pc := range percentiles {
+		if pc < 0 || pc > 100 || (i > 0 && pc < percentiles[i-1]) {
+			return nil, invalidParams("invalid reward percentile: %v", pc)
+		}
+	}
+
+	h := FeeHistory{OldestBlock: "0x0", BaseFeePerGas: []Quantity{}, GasUsedRatio: []float64{}}
+
+	if count == 0 {
+		return h, nil
+	}
+
+	if count > 1024 {
+		count = 1024
+	}
+
+	if count > newest.number+1 {
+		count = newest.number + 1
+	}
+
+	oldest := newest.number + 1 - count
+	h.OldestBlock = hexUint(oldest)
+
+	for _, b := range n.blocks[oldest : newest.number+1] {
+		h.BaseFeePerGas = append(h.BaseFeePerGas, hexBig(b.baseFee))
+		h.GasUsedRatio = append(h.GasUsedRatio, float64(b.gasUsed)/mockGasLimit)
+
+		if percentiles == nil {
+			continue
+		}
+
+		tip := new(big.Int)
+		if len(b.txs) > 0 {
+			tip.Sub(b.txs[0].gasPrice, b.baseFee)
+		}
+
+		rewards := make([]Quantity, 0, len(percentiles))
+		for range percentiles {
+			rewards = append(rewards, hexBig(tip))
+		}
+
+		h.Reward = append(h.Reward, rewards)
+	}
+
+	h.BaseFeePerGas = append(h.BaseFeePerGas, hexBig(nextBaseFee(newest)))
+
+	return h, nil
+}
+
+func (n *MockNode) accounts(mockParams) (interface{}, error) {
+	accounts := make([]Address, 0, len(n.signers))
+	for _, s := range n.signers {
+		accounts = append(accounts, Address(hexBytes(s.address)))
+	}
+
+	return accounts, nil
+}
+
+func (n *MockNode) blockNumber(mockParams) (interface{}, error) {
+	return hexUint(n.head().number), nil
+}
+
+func (n *MockNode) getBalance(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return hexBig(state.get(addr).balance), nil
+}
+
+func (n *MockNode) getStorageAt(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var position Quantity
+
+	if err := p.get(1, &position); err != nil {
+		return nil, err
+	}
+
+	slot, err := parseQuantity(position)
+	if err != nil || slot.BitLen() > 256 {
+		return nil, invalidParams("invalid argument 1: invalid storage position")
+	}
+
+	state, err := n.paramState(p, 2, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return Data(hexBytes(leftPad(state.get(addr).storage[hex.EncodeToString(word(slot))], 32))), nil
+}
+
+func (n *MockNode) getTransactionCount(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return hexUint(state.get(addr).nonce), nil
+}
+
+func (n *MockNode) getCode(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return Data(hexBytes(state.get(addr).code)), nil
+}
+
+// getProof returns account and storage values with empty proofs, state is not kept in tries.
+func (n *MockNode) getProof(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []Hash
+
+	if err := p.get(1, &keys); err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 2, true)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := state.get(addr)
+
+	storageRoot, err := acc.storageRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	proof := AccountProof{
+		Address:      Address(hexBytes(addr)),
+		AccountProof: []Data{},
+		Balance:      hexBig(acc.balance),
+		CodeHash:     Hash(hexBytes(keccak256(acc.code))),
+		Nonce:        hexUint(acc.nonce),
+		StorageHash:  Hash(hexBytes(storageRoot)),
+		StorageProof: make([]StorageProof, 0, len(keys)),
+	}
+
+	for _, k := range keys {
+		key, err := parseHex(string(k))
+		if err != nil || len(key) > 32 {
+			return nil, invalidParams("invalid argument 1: invalid storage key %q", k)
+		}
+
+		proof.StorageProof = append(proof.StorageProof, StorageProof{
+			Key:   k,
+			Value: hexBig(new(big.Int).SetBytes(acc.storage[hex.EncodeToString(leftPad(key, 32))])),
+			Proof: []Data{},
+		})
+	}
+
+	return proof, nil
+}
+
+func (n *MockNode) getBlockTransactionCountByHash(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if b := n.blockByHash(hash); b != nil {
+		return hexUint(uint64(len(b.txs))), nil
+	}
+
+	return nil, nil
+}
+
+func (n *MockNode) getBlockTransactionCountByNumber(p mockParams) (interface{}, error) {
+	b, err := n.paramBlock(p, 0, true)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	return hexUint(uint64(len(b.txs))), nil
+}
+
+func (n *MockNode) getUncleCountByBlockHash(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.blockByHash(hash) != nil {
+		return Quantity("0x0"), nil
+	}
+
+	return nil, nil
+}
+
+func (n *MockNode) getUncleCountByBlockNumber(p mockParams) (interface{}, error) {
+	b, err := n.paramBlock(p, 0, true)
+	if err != nil || b == nil {
+		return nil, err
+	}
+
+	return Quantity("0x0"), nil
+}
+
+func (n *MockNode) sign(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var d Data
+
+	if err := p.get(1, &d); err != nil {
+		return nil, err
+	}
+
+	message, err := parseHex(string(d))
+	if err != nil {
+		return nil, invalidParams("invalid argument 1: %v", err)
+	}
+
+	s, err := n.unlocked(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return signMessage(s, message), nil
+}
+
+// signMessage signs message prefixed as in eth_sign, v of the signature is 27 or 28.
+func signMessage(s signer, message []byte) Data {
+	r, sig, recID := s.sign(personalHash(message))
+
+	return Data(hexBytes(append(append(word(r), word(sig)...), 27+recID)))
+}
+
+func (n *MockNode) sendTransaction(p mockParams) (interface{}, error) {
+	var args TransactionArgs
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	return n.sendArgs(args, n.unlocked)
+}
+
+// sendArgs sends transaction of args signed by the sender account.
+func (n *MockNode) sendArgs(args TransactionArgs, account func(address []byte) (signer, error)) (interface{}, error) {
+	if args.From == nil {
+		return nil, invalidParams("from is required")
+	}
+
+	from, to, value, data, err := args.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := account(from)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := n.send(s, args, to, value, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return Hash(hexBytes(tx.hash)), nil
+}
+
+func (n *MockNode) sendRawTransaction(p mockParams) (interface{}, error) {
+	var d Data
+
+	if err := p.get(0, &d); err != nil {
+		return nil, err
+	}
+
+	raw, err := parseHex(string(d))
+	if err != nil {
+		return nil, invalidParams("invalid argument 0: %v", err)
+	}
+
+	tx, err := n.decodeRawTx(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.mine(tx); err != nil {
+		return nil, err
+	}
+
+	return Hash(hexBytes(tx.hash)), nil
+}
+
+func (n *MockNode) getBlockByHash(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var full bool
+
+	if err := p.get(1, &full); err != nil {
+		return nil, err
+	}
+
+	if b := n.blockByHash(hash); b != nil {
+		return b.render(full), nil
+	}
+
+	return nil, nil
+}
+
+func (n *MockNode) getBlockByNumber(p mockParams) (interface{}, error) {
+	b, err := n.paramBlock(p, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var full bool
+
+	if err := p.get(1, &full); err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, nil
+	}
+
+	return b.render(full), nil
+}
+
+func (n *MockNode) getTransactionByHash(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref, ok := n.txs[hexBytes(hash)]; ok {
+		return ref.block.txs[ref.index].render(ref.block, ref.index), nil
+	}
+
+	return nil, nil
+}
+
+func (n *MockNode) getTransactionByBlockHashAndIndex(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := p.uint(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return n.blockByHash(hash).transaction(index), nil
+}
+
+func (n *MockNode) getTransactionByBlockNumberAndIndex(p mockParams) (interface{}, error) {
+	b, err := n.paramBlock(p, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := p.uint(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.transaction(index), nil
+}
+
+func (n *MockNode) getTransactionReceipt(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref, ok := n.txs[hexBytes(hash)]; ok {
+		return ref.block.receipt(ref.index), nil
+	}
+
+	return nil, nil
+}
+
+// getBlockReceipts returns receipts of a block by number, tag or hash.
+func (n *MockNode) getBlockReceipts(p mockParams) (interface{}, error) {
+	var (
+		id  string
+		b   *mockBlock
+		err error
+	)
+
+	if err := p.get(0, &id); err != nil {
+		return nil, err
+	}
+
+	if len(id) == 66 {
+		hash, err := p.hash(0)
+		if err != nil {
+			return nil, err
+		}
+
+		b = n.blockByHash(hash)
+	} else if b, err = n.blockByTag(BlockTag(id)); err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, nil
+	}
+
+	receipts := make([]Receipt, 0, len(b.txs))
+	for i := range b.txs {
+		receipts = append(receipts, b.receipt(i))
+	}
+
+	return receipts, nil
+}
+
+func (n *MockNode) newFilter(p mockParams) (interface{}, error) {
+	var q FilterObject
+
+	if err := p.get(0, &q); err != nil {
+		return nil, err
+	}
+
+	if _, _, err := n.logRange(q); err != nil {
+		return nil, err
+	}
+
+	return n.addFilter(&mockFilter{kind: logFilter, query: q}), nil
+}
+
+func (n *MockNode) newBlockFilter(mockParams) (interface{}, error) {
+	return n.addFilter(&mockFilter{kind: blockFilter}), nil
+}
+
+func (n *MockNode) newPendingTransactionFilter(mockParams) (interface{}, error) {
+	return n.addFilter(&mockFilter{kind: pendingFilter}), nil
+}
+
+func (n *MockNode) addFilter(f *mockFilter) Quantity {
+	n.lastFilterID++
+	id := hexUint(n.lastFilterID)
+	f.next = n.head().number + 1
+	n.filters[id] = f
+
+	return id
+}
+
+func (n *MockNode) filter(p mockParams) (Quantity, *mockFilter, error) {
+	var id Quantity
+
+	if err := p.get(0, &id); err != nil {
+		return "", nil, err
+	}
+
+	f, ok := n.filters[id]
+	if !ok {
+		return id, nil, execError("filter not found")
+	}
+
+	return id, f, nil
+}
+
+func (n *MockNode) uninstallFilter(p mockParams) (interface{}, error) {
+	id, _, err := n.filter(p)
+	if err != nil {
+		return false, nil // nolint:nilerr // Unknown filter is reported with false result.
+	}
+
+	delete(n.filters, id)
+
+	return true, nil
+}
+
+func (n *MockNode) getFilterChanges(p mockParams) (interface{}, error) {
+	_, f, err := n.filter(p)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := FilterChanges{}
+	head := n.head().number
+
+	switch f.kind {
+	case blockFilter:
+		for _, b := range n.blocks[f.next:] {
+			h := Hash(hexBytes(b.hash))
+			changes = append(changes, LogOrHash{Hash: &h})
+		}
+	case pendingFilter:
+		for i := range f.hashes {
+			changes = append(changes, LogOrHash{Hash: &f.hashes[i]})
+		}
+
+		f.hashes = nil
+	default:
+		logs, err := n.logs(f.query, f.next)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = logs
+	}
+
+	f.next = head + 1
+
+	return changes, nil
+}
+
+func (n *MockNode) getFilterLogs(p mockParams) (interface{}, error) {
+	_, f, err := n.filter(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.kind != logFilter {
+		return nil, execError("filter not found")
+	}
+
+	return n.logs(f.query, 0)
+}
+
+func (n *MockNode) getLogs(p mockParams) (interface{}, error) {
+	var q FilterObject
+
+	if err := p.get(0, &q); err != nil {
+		return nil, err
+	}
+
+	return n.logs(q, 0)
+}
+
+// logRange returns blocks matched by filter query.
+func (n *MockNode) logRange(q FilterObject) (from, to uint64, err error) {
+	if q.BlockHash != nil {
+		if q.FromBlock != nil || q.ToBlock != nil {
+			return 0, 0, invalidParams("blockHash excludes fromBlock and toBlock")
+		}
+
+		hash, err := parseHex(string(*q.BlockHash))
+		if err != nil {
+			return 0, 0, invalidParams("invalid blockHash: %v", err)
+		}
+
+		b := n.blockByHash(hash)
+		if b == nil {
+			return 0, 0, execError("unknown block")
+		}
+
+		return b.number, b.number, nil
+	}
+
+	bound := func(tag *BlockTag) (uint64, error) {
+		if tag == nil {
+			return n.head().number, nil
+		}
+
+		b, err := n.blockByTag(*tag)
+		if err != nil {
+			return 0, err
+		}
+
+		if b == nil {
+			return n.head().number, nil
+		}
+
+		return b.number, nil
+	}
+
+	if from, err = bound(q.FromBlock); err != nil {
+		return 0, 0, err
+	}
+
+	if to, err = bound(q.ToBlock); err != nil {
+		return 0, 0, err
+	}
+
+	return from, to, nil
+}
+
+// logs returns logs matched by filter query in blocks starting from a given number.
+func (n *MockNode) logs(q FilterObject, since uint64) (FilterChanges, error) {
+	from, to, err := n.logRange(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if from < since {
+		from = since
+	}
+
+	changes := FilterChanges{}
+
+	for i := from; i <= to && i < uint64(len(n.blocks)); i++ {
+		for _, l := range n.blocks[i].logs() {
+			if q.matches(l) {
+				l := l
+				changes = append(changes, LogOrHash{Log: &l})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// matches checks whether log address and topics satisfy filter query.
+func (q FilterObject) matches(l Log) bool {
+	if len(q.Address) > 0 {
+		found := false
+
+		for _, a := range q.Address {
+			if strings.EqualFold(string(a), string(l.Address)) {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+
+		if i >= len(l.Topics) {
+			return false
+		}
+
+		found := false
+
+		for _, t := range alternatives {
+			if strings.EqualFold(string(t), string(l.Topics[i])) {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *MockNode) call(p mockParams) (interface{}, error) {
+	var args TransactionArgs
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	from, to, value, data, err := args.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	state = state.copy()
+
+	_, _, _, ret, err := state.run(from, to, value, data, state.account(from).nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return Data(hexBytes(ret)), nil
+}
+
+func (n *MockNode) estimateGas(p mockParams) (interface{}, error) {
+	var args TransactionArgs
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	from, to, value, data, err := args.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := state.copy()
+
+	gas, _, _, _, err := exec.run(from, to, value, data, exec.account(from).nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var accessList AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
+	return hexUint(gas + intrinsicGas(data, to == nil) + accessListGas(accessList)), nil
+}
+
+// createAccessList lists storage slots of the called contract that execution loads or stores.
+func (n *MockNode) createAccessList(p mockParams) (interface{}, error) {
+	var args TransactionArgs
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	from, to, value, data, err := args.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, false)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := state.copy()
+	list := AccessList{}
+
+	var contract *mockAccount
+
+	if to != nil && len(exec.get(to).code) > 0 {
+		contract = exec.account(to)
+		contract.accessed = []string{}
+	}
+
+	gas, _, _, _, runErr := exec.run(from, to, value, data, exec.account(from).nonce)
+
+	if contract != nil && len(contract.accessed) > 0 {
+		keys := make([]Hash, 0, len(contract.accessed))
+		for _, slot := range contract.accessed {
+			keys = append(keys, Hash("0x"+slot))
+		}
+
+		list = append(list, AccessTuple{Address: Address(hexBytes(to)), StorageKeys: keys})
+	}
+
+	res := AccessListResult{
+		AccessList: list,
+		GasUsed:    hexUint(gas + intrinsicGas(data, to == nil) + accessListGas(list)),
+	}
+
+	if runErr != nil {
+		res.Error = runErr.Error()
+	}
+
+	return res, nil
+}
+
+// fees sets type, fee caps or gas price and access list of a transaction to send from args.
+func (a TransactionArgs) fees(tx *mockTx) error {
+	if a.GasPrice != nil && (a.MaxFeePerGas != nil || a.MaxPriorityFeePerGas != nil) {
+		return invalidParams("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	}
+
+	switch {
+	case a.Type != nil:
+		typ, err := parseUint(*a.Type)
+		if err != nil || typ > DynamicFeeTxType {
+			return invalidParams("unsupported transaction type %s", *a.Type)
+		}
+
+		tx.typ = byte(typ)
+	case a.GasPrice != nil && a.AccessList != nil:
+		tx.typ = AccessListTxType
+	case a.GasPrice != nil:
+		tx.typ = LegacyTxType
+	}
+
+	switch {
+	case tx.typ == DynamicFeeTxType && a.GasPrice != nil:
+		return invalidParams("gasPrice is not supported by EIP-1559 transactions, use maxFeePerGas")
+	case tx.typ != DynamicFeeTxType && (a.MaxFeePerGas != nil || a.MaxPriorityFeePerGas != nil):
+		return invalidParams("maxFeePerGas and maxPriorityFeePerGas require EIP-1559 transaction")
+	case tx.typ == LegacyTxType && a.AccessList != nil:
+		return invalidParams("access list is not supported by legacy transactions")
+	}
+
+	if tx.typ != LegacyTxType {
+		tx.accessList = AccessList{}
+
+		if a.AccessList != nil {
+			tx.accessList = *a.AccessList
+		}
+	}
+
+	if a.ChainID != nil {
+		id, err := parseQuantity(*a.ChainID)
+		if err != nil {
+			return invalidParams("invalid chainId: %v", err)
+		}
+
+		if id.Cmp(tx.chainID) != 0 {
+			return execError("chainId does not match node's (have=%s, want=%s)", id, tx.chainID)
+		}
+	}
+
+	for _, f := range []struct {
+		name  string
+		value *Quantity
+		dst   **big.Int
+	}{
+		{name: "gasPrice", value: a.GasPrice, dst: &tx.gasPrice},
+		{name: "maxFeePerGas", value: a.MaxFeePerGas, dst: &tx.feeCap},
+		{name: "maxPriorityFeePerGas", value: a.MaxPriorityFeePerGas, dst: &tx.tipCap},
+	} {
+		if f.value == nil {
+			continue
+		}
+
+		v, err := parseQuantity(*f.value)
+		if err != nil {
+			return invalidParams("invalid %s: %v", f.name, err)
+		}
+
+		*f.dst = v
+	}
+
+	return nil
+}
+
+// decode returns sender, recipient, value and data of transaction args, zero address is the default sender.
+func (a TransactionArgs) decode() (from, to []byte, value *big.Int, data []byte, err error) {
+	from = make([]byte, 20)
+	value = new(big.Int)
+
+	if a.From != nil {
+		if from, err = parseAddress(*a.From); err != nil {
+			return nil, nil, nil, nil, invalidParams("invalid from: %v", err)
+		}
+	}
+
+	if a.To != nil {
+		if to, err = parseAddress(*a.To); err != nil {
+			return nil, nil, nil, nil, invalidParams("invalid to: %v", err)
+		}
+	}
+
+	if a.Value != nil {
+		if value, err = parseQuantity(*a.Value); err != nil {
+			return nil, nil, nil, nil, invalidParams("invalid value: %v", err)
+		}
+	}
+
+	input := a.Input
+	if input == nil {
+		input = a.Data
+	}
+
+	if input != nil {
+		if data, err = parseHex(string(*input)); err != nil {
+			return nil, nil, nil, nil, invalidParams("invalid input: %v", err)
+		}
+	}
+
+	return from, to, value, data, nil
+}
+
+// render returns block object, with full transactions if requested.
+func (b *mockBlock) render(full bool) Block {
+	var (
+		number  = hexUint(b.number)
+		hash    = Hash(hexBytes(b.hash))
+		baseFee = hexBig(b.baseFee)
+		nonce   = Data("0x0000000000000000")
+		bloom   = Data(hexBytes(b.bloom))
+		size    = 540
+		txs     = make(BlockTransactions, 0, len(b.txs))
+	)
+
+	for i, tx := range b.txs {
+		size += len(tx.raw)
+
+		if full {
+			t := tx.render(b, i)
+			txs = append(txs, TransactionOrHash{Transaction: &t})
+		} else {
+			h := Hash(hexBytes(tx.hash))
+			txs = append(txs, TransactionOrHash{Hash: &h})
+		}
+	}
+
+	return Block{
+		Number:           &number,
+		Hash:             &hash,
+		ParentHash:       Hash(hexBytes(b.parentHash)),
+		Nonce:            &nonce,
+		Sha3Uncles:       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		LogsBloom:        &bloom,
+		TransactionsRoot: Hash(hexBytes(b.txRoot)),
+		StateRoot:        Hash(hexBytes(b.stateRoot)),
+		ReceiptsRoot:     Hash(hexBytes(b.receiptsRoot)),
+		Miner:            Address(hexBytes(make([]byte, 20))),
+		Difficulty:       "0x0",
+		TotalDifficulty:  "0x0",
+		ExtraData:        "0x",
+		Size:             hexUint(uint64(size)),
+		GasLimit:         hexUint(mockGasLimit),
+		GasUsed:          hexUint(b.gasUsed),
+		Timestamp:        hexUint(b.timestamp),
+		BaseFeePerGas:    &baseFee,
+		Transactions:     txs,
+		Uncles:           []Hash{},
+	}
+}
+
+// transaction returns transaction by index, nil if block or transaction does not exist.
+func (b *mockBlock) transaction(index uint64) *Transaction {
+	if b == nil || index >= uint64(len(b.txs)) {
+		return nil
+	}
+
+	t := b.txs[index].render(b, int(index))
+
+	return &t
+}
+
+func (tx *mockTx) render(b *mockBlock, index int) Transaction {
+	var (
+		blockHash   = Hash(hexBytes(b.hash))
+		blockNumber = hexUint(b.number)
+		txIndex     = hexUint(uint64(index))
+		to          *Address
+	)
+
+	if tx.to != nil {
+		a := Address(hexBytes(tx.to))
+		to = &a
+	}
+
+	t := Transaction{
+		BlockHash:        &blockHash,
+		BlockNumber:      &blockNumber,
+		From:             Address(hexBytes(tx.from)),
+		Gas:              hexUint(tx.gas),
+		GasPrice:         hexBig(tx.gasPrice),
+		Hash:             Hash(hexBytes(tx.hash)),
+		Input:            Data(hexBytes(tx.data)),
+		Nonce:            hexUint(tx.nonce),
+		To:               to,
+		TransactionIndex: &txIndex,
+		Value:            hexBig(tx.value),
+		Type:             hexUint(uint64(tx.typ)),
+		V:                hexBig(tx.v),
+		R:                hexBig(tx.r),
+		S:                hexBig(tx.s),
+	}
+
+	if tx.chainID != nil {
+		chainID := hexBig(tx.chainID)
+		t.ChainID = &chainID
+	}
+
+	if tx.typ != LegacyTxType {
+		accessList, yParity := tx.accessList, hexBig(tx.v)
+		t.AccessList, t.YParity = &accessList, &yParity
+	}
+
+	if tx.typ == DynamicFeeTxType {
+		feeCap, tipCap := hexBig(tx.feeCap), hexBig(tx.tipCap)
+		t.MaxFeePerGas, t.MaxPriorityFeePerGas = &feeCap, &tipCap
+	}
+
+	return t
+}
+
+func (b *mockBlock) receipt(index int) Receipt {
+	var (
+		tx       = b.txs[index]
+		r        = b.receipts[index]
+		to       *Address
+		contract *Address
+	)
+
+	if tx.to != nil {
+		a := Address(hexBytes(tx.to))
+		to = &a
+	}
+
+	if r.contractAddress != nil {
+		a := Address(hexBytes(r.contractAddress))
+		contract = &a
+	}
+
+	logs := []Log{}
+
+	for _, l := range b.logs() {
+		if *l.TransactionIndex == hexUint(uint64(index)) {
+			logs = append(logs, l)
+		}
+	}
+
+	return Receipt{
+		TransactionHash:   Hash(hexBytes(tx.hash)),
+		TransactionIndex:  hexUint(uint64(index)),
+		BlockHash:         Hash(hexBytes(b.hash)),
+		BlockNumber:       hexUint(b.number),
+		From:              Address(hexBytes(tx.from)),
+		To:                to,
+		CumulativeGasUsed: hexUint(r.cumulativeGasUsed),
+		GasUsed:           hexUint(r.gasUsed),
+		ContractAddress:   contract,
+		Logs:              logs,
+		LogsBloom:         Data(hexBytes(r.bloom)),
+		Status:            hexUint(r.status),
+		Type:              hexUint(uint64(tx.typ)),
+		EffectiveGasPrice: hexBig(tx.gasPrice),
+	}
+}
+
+// logs returns all logs of the block with their positions.
+func (b *mockBlock) logs() []Log {
+	var (
+		logs      []Log
+		blockHash = Hash(hexBytes(b.hash))
+		number    = hexUint(b.number)
+	)
+
+	for i, r := range b.receipts {
+		txIndex := hexUint(uint64(i))
+		txHash := Hash(hexBytes(b.txs[i].hash))
+
+		for _, l := range r.logs {
+			logIndex := hexUint(uint64(len(logs)))
+			topics := make([]Hash, 0, len(l.topics))
+
+			for _, t := range l.topics {
+				topics = append(topics, Hash(hexBytes(t)))
+			}
+
+			logs = append(logs, Log{
+				LogIndex:         &logIndex,
+				TransactionIndex: &txIndex,
+				TransactionHash:  &txHash,
+				BlockHash:        &blockHash,
+				BlockNumber:      &number,
+				Address:          Address(hexBytes(l.address)),
+				Data:             Data(hexBytes(l.data)),
+				Topics:           topics,
+			})
+		}
+	}
+
+	return logs
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Transactions are mined as soon as they are sent, so the pool of the mock node is always empty.
+
+func (n *MockNode) txPoolContent(mockParams) (interface{}, error) {
+	return TxPoolContent{
+		Pending: map[Address]map[string]Transaction{},
+		Queued:  map[Address]map[string]Transaction{},
+	}, nil
+}
+
+func (n *MockNode) txPoolInspect(mockParams) (interface{}, error) {
+	return TxPoolInspect{
+		Pending: map[Address]map[string]string{},
+		Queued:  map[Address]map[string]string{},
+	}, nil
+}
+
+// newAccount adds an account with a key derived from the number of accounts, so that it is deterministic.
+func (n *MockNode) newAccount(p mockParams) (interface{}, error) {
+	var password string
+
+	if err := p.get(0, &password); err != nil {
+		return nil, err
+	}
+
+	key := keccak256([]byte("mock account "), []byte(strconv.Itoa(len(n.signers))))
+
+	return n.addAccount(key, password)
+}
+
+func (n *MockNode) importRawKey(p mockParams) (interface{}, error) {
+	var hexKey, password string
+
+	if err := p.get(0, &hexKey); err != nil {
+		return nil, err
+	}
+
+	if err := p.get(1, &password); err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil || len(key) != 32 || new(big.Int).SetBytes(key).Sign() == 0 {
+		return nil, invalidParams("invalid argument 0: key must be 32 hex encoded bytes")
+	}
+
+	return n.addAccount(key, password)
+}
+
+// addAccount adds a locked account protected with password.
+func (n *MockNode) addAccount(key []byte, password string) (interface{}, error) {
+	s := newSigner(hex.EncodeToString(key))
+	if _, exists := n.signer(s.address); exists {
+		return nil, execError("account already exists")
+	}
+
+	n.signers = append(n.signers, s)
+	n.passwords[hexBytes(s.address)] = password
+	n.locked[hexBytes(s.address)] = true
+
+	return Address(hexBytes(s.address)), nil
+}
+
+// unlockAccount unlocks account until it is locked again, duration is ignored.
+func (n *MockNode) unlockAccount(p mockParams) (interface{}, error) {
+	var (
+		password string
+		duration uint64
+	)
+
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.get(1, &password); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.optional(2, &duration); err != nil {
+		return nil, err
+	}
+
+	if _, err := n.authenticate(addr, password); err != nil {
+		return nil, err
+	}
+
+	delete(n.locked, hexBytes(addr))
+
+	return true, nil
+}
+
+func (n *MockNode) lockAccount(p mockParams) (interface{}, error) {
+	addr, err := p.address(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := n.signer(addr); !ok {
+		return false, nil
+	}
+
+	n.locked[hexBytes(addr)] = true
+
+	return true, nil
+}
+
+func (n *MockNode) personalSendTransaction(p mockParams) (interface{}, error) {
+	var (
+		args     TransactionArgs
+		password string
+	)
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	if err := p.get(1, &password); err != nil {
+		return nil, err
+	}
+
+	return n.sendArgs(args, func(address []byte) (signer, error) {
+		return n.authenticate(address, password)
+	})
+}
+
+func (n *MockNode) personalSign(p mockParams) (interface{}, error) {
+	var (
+		d        Data
+		password string
+	)
+
+	if err := p.get(0, &d); err != nil {
+		return nil, err
+	}
+
+	message, err := parseHex(string(d))
+	if err != nil {
+		return nil, invalidParams("invalid argument 0: %v", err)
+	}
+
+	addr, err := p.address(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.get(2, &password); err != nil {
+		return nil, err
+	}
+
+	s, err := n.authenticate(addr, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return signMessage(s, message), nil
+}
+
+// ecRecover returns address that signed message with personal_sign or eth_sign.
+func (n *MockNode) ecRecover(p mockParams) (interface{}, error) {
+	var d, sig Data
+
+	if err := p.get(0, &d); err != nil {
+		return nil, err
+	}
+
+	if err := p.get(1, &sig); err != nil {
+		return nil, err
+	}
+
+	message, err := parseHex(string(d))
+	if err != nil {
+		return nil, invalidParams("invalid argument 0: %v", err)
+	}
+
+	b, err := parseHex(string(sig))
+	if err != nil || len(b) != 65 {
+		return nil, invalidParams("invalid argument 1: signature must be 65 bytes long")
+	}
+
+	if b[64] != 27 && b[64] != 28 {
+		return nil, execError("invalid Ethereum signature (V is not 27 or 28)")
+	}
+
+	r, s := new(big.Int).SetBytes(b[:32]), new(big.Int).SetBytes(b[32:64])
+
+	addr, err := recoverAddress(personalHash(message), r, s, b[64]-27)
+	if err != nil {
+		return nil, execError("%v", err)
+	}
+
+	return Address(hexBytes(addr)), nil
+}
+
+// unlocked returns signer of an account that is not locked.
+func (n *MockNode) unlocked(address []byte) (signer, error) {
+	s, ok := n.signer(address)
+	if !ok {
+		return s, execError("unknown account")
+	}
+
+	if n.locked[hexBytes(address)] {
+		return s, execError("authentication needed: password or unlock")
+	}
+
+	return s, nil
+}
+
+// authenticate returns signer of an account with password, development accounts take any password.
+func (n *MockNode) authenticate(address []byte, password string) (signer, error) {
+	s, ok := n.signer(address)
+	if !ok {
+		return s, execError("unknown account")
+	}
+
+	if expected, ok := n.passwords[hexBytes(address)]; ok && expected != password {
+		return s, execError("could not decrypt key with given password")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mockTendermintVersion = "0.37.4"
+	mockVotingPower       = "10"
+	mockSearchPerPage     = 30
+	mockSearchMaxPerPage  = 100
+)
+
+// mockTendermintMethods maps Tendermint RPC methods to handlers and their number of params,
+// Tendermint requires every param to be passed, null stands for the default value.
+var mockTendermintMethods = map[string]struct {
+	params int
+	handle func(t mockTendermint, p mockParams) (interface{}, error)
+}{
+	"status":    {0, mockTendermint.status},
+	"block":     {1, mockTendermint.block},
+	"tx_search": {5, mockTendermint.txSearch},
+}
+
+// searchCondition matches a condition of tx_search query, e.g. tx.height=5 or ethereum_tx.ethereumTxHash='0x...'.
+var searchCondition = regexp.MustCompile(`^\s*([\w.]+)\s*=\s*(?:'([^']*)'|(\d+))\s*$`)
+
+// mockTendermint answers Tendermint RPC methods from the chain of a mock node.
+//
+// Every Ethereum block but genesis is a Tendermint block with the same height and hash, signed by
+// a single validator. Blocks contain raw Ethereum transactions instead of Cosmos transactions that
+// wrap them on Ethermint based chains, their results have ethereum_tx events as on such chains.
+type mockTendermint struct {
+	n *MockNode
+}
+
+// Tendermint returns caller of Tendermint RPC methods answered from the node chain.
+func (n *MockNode) Tendermint() Caller {
+	return mockTendermint{n: n}
+}
+
+// Call answers Tendermint RPC method.
+func (t mockTendermint) Call(_ context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	m, ok := mockTendermintMethods[method]
+	if !ok {
+		return nil, methodNotFound(method)
+	}
+
+	var p mockParams
+
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, invalidParams("params must be an array: %v", err)
+		}
+	}
+
+	if len(p) != m.params {
+		return nil, invalidParams("expected %d parameters, got %d", m.params, len(p))
+	}
+
+	t.n.mu.Lock()
+	defer t.n.mu.Unlock()
+
+	result, err := m.handle(t, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
+
+func (t mockTendermint) status(mockParams) (interface{}, error) {
+	latest, earliest := t.n.head(), t.n.blocks[1]
+	validator := mockValidatorKey()
+
+	return ResultStatus{
+		NodeInfo: NodeInfo{
+			ProtocolVersion: ProtocolVersion{P2P: "8", Block: "11", App: "0"},
+			ID:              hex.EncodeToString(tmHash([]byte("mock node"))[:20]),
+			ListenAddr:      "tcp://0.0.0.0:26656",
+			Network:         t.chainID(),
+			Version:         mockTendermintVersion,
+			Channels:        "40202122233038606100",
+			Moniker:         "mock",
+			Other:           NodeInfoOther{TxIndex: "on", RPCAddress: "tcp://0.0.0.0:26657"},
+		},
+		SyncInfo: SyncInfo{
+			LatestBlockHash:     upperHex(latest.hash),
+			LatestAppHash:       upperHex(latest.stateRoot),
+			LatestBlockHeight:   Int64(strconv.FormatUint(latest.number, 10)),
+			LatestBlockTime:     blockTime(latest),
+			EarliestBlockHash:   upperHex(earliest.hash),
+			EarliestAppHash:     upperHex(earliest.stateRoot),
+			EarliestBlockHeight: Int64(strconv.FormatUint(earliest.number, 10)),
+			EarliestBlockTime:   blockTime(earliest),
+		},
+		ValidatorInfo: ValidatorInfo{
+			Address:     upperHex(mockValidatorAddress()),
+			PubKey:      PubKey{Type: "tendermint/PubKeyEd25519", Value: base64Bytes(validator)},
+			VotingPower: mockVotingPower,
+		},
+	}, nil
+}
+
+func (t mockTendermint) block(p mockParams) (interface{}, error) {
+	height, err := p.int64(0, int64(t.n.head().number))
+	if err != nil {
+		return nil, err
+	}
+
+	if height <= 0 {
+		return nil, execError("height must be greater than 0, but got %d", height)
+	}
+
+	if height > int64(t.n.head().number) {
+		return nil, execError("height %d must be less than or equal to the current blockchain height %d",
+			height, t.n.head().number)
+	}
+
+	b := t.n.blocks[height]
+
+	return ResultBlock{BlockID: blockID(b), Block: t.render(b)}, nil
+}
+
+// txSearch finds transactions with events matching every condition of the query.
+func (t mockTendermint) txSearch(p mockParams) (interface{}, error) {
+	var (
+		query   string
+		prove   bool
+		orderBy string
+	)
+
+	if err := p.get(0, &query); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.optional(1, &prove); err != nil {
+		return nil, err
+	}
+
+	page, err := p.int64(2, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	perPage, err := p.int64(3, mockSearchPerPage)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.optional(4, &orderBy); err != nil {
+		return nil, err
+	}
+
+	conditions, err := parseSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []ResultTx
+
+	for _, b := range t.n.blocks[1:] {
+		for i := range b.txs {
+			if r := t.txResult(b, i, prove); r.matches(conditions) {
+				found = append(found, r)
+			}
+		}
+	}
+
+	switch orderBy {
+	case "", "asc":
+	case "desc":
+		for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+			found[i], found[j] = found[j], found[i]
+		}
+	default:
+		return nil, execError("expected order_by to be either `asc` or `desc` or empty")
+	}
+
+	if perPage < 1 || perPage > mockSearchMaxPerPage {
+		perPage = mockSearchMaxPerPage
+	}
+
+	pages := (int64(len(found)) + perPage - 1) / perPage
+	if pages == 0 {
+		pages = 1
+	}
+
+	if page < 1 || page > pages {
+		return nil, execError("page should be within [1, %d] range, given %d", pages, page)
+	}
+
+	start, end := (page-1)*perPage, page*perPage
+	if end > int64(len(found)) {
+		end = int64(len(found))
+	}
+
+	return ResultTxSearch{
+		Txs:        append([]ResultTx{}, found[start:end]...),
+		TotalCount: Int64(strconv.Itoa(len(found))),
+	}, nil
+}
+
+// parseSearchQuery splits query into conditions, only equality conditions joined with AND are supported.
+func parseSearchQuery(query string) ([][2]string, error) {
+	var conditions [][2]string
+
+	for _, c := range strings.Split(query, " AND ") {
+		m := searchCondition.FindStringSubmatch(c)
+		if m == nil {
+			return nil, execError("failed to parse query: unsupported condition %q, the mock node supports key='value' "+
+				"and key=number conditions joined with AND", strings.TrimSpace(c))
+		}
+
+		conditions = append(conditions, [2]string{m[1], m[2] + m[3]})
+	}
+
+	return conditions, nil
+}
+
+// matches tells whether transaction height, hash or events satisfy every condition.
+func (r ResultTx) matches(conditions [][2]string) bool {
+	for _, c := range conditions {
+		key, value := c[0], c[1]
+
+		switch key {
+		case "tx.height":
+			if string(r.Height) != value {
+				return false
+			}
+		case "tx.hash":
+			if !strings.EqualFold(string(r.Hash), value) {
+				return false
+			}
+		default:
+			if !r.TxResult.hasAttribute(key, value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasAttribute tells whether an event has attribute with the value, key is "<event type>.<attribute key>".
+func (r TxResult) hasAttribute(key, value string) bool {
+	for _, e := range r.Events {
+		for _, a := range e.Attributes {
+			if e.Type+"."+a.Key == key && a.Value == value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// render returns block with its transactions and commit of the parent block.
+func (t mockTendermint) render(b *mockBlock) TendermintBlock {
+	var (
+		parent = t.n.blocks[b.number-1]
+		txs    = make([]Base64, 0, len(b.txs))
+		commit = Commit{Height: Int64(strconv.FormatUint(parent.number, 10)), Signatures: []CommitSig{}}
+	)
+
+	for _, tx := range b.txs {
+		txs = append(txs, base64Bytes(tx.raw))
+	}
+
+	// Genesis is not a Tendermint block, the first block has no previous block and commit.
+	if parent.number > 0 {
+		sig := sha512.Sum512(parent.hash)
+		signature := base64Bytes(sig[:])
+
+		commit.BlockID = blockID(parent)
+		commit.Signatures = append(commit.Signatures, CommitSig{
+			BlockIDFlag:      2,
+			ValidatorAddress: upperHex(mockValidatorAddress()),
+			Timestamp:        blockTime(b),
+			Signature:        &signature,
+		})
+	}
+
+	return TendermintBlock{
+		Header: BlockHeader{
+			Version:            Consensus{Block: "11", App: "0"},
+			ChainID:            t.chainID(),
+			Height:             Int64(strconv.FormatUint(b.number, 10)),
+			Time:               blockTime(b),
+			LastBlockID:        commit.BlockID,
+			LastCommitHash:     upperHex(tmHash([]byte("commit"), parent.hash)),
+			DataHash:           upperHex(dataHash(b)),
+			ValidatorsHash:     upperHex(tmHash([]byte("validators"), mockValidatorKey())),
+			NextValidatorsHash: upperHex(tmHash([]byte("validators"), mockValidatorKey())),
+			ConsensusHash:      upperHex(tmHash([]byte("consensus params"))),
+			AppHash:            upperHex(parent.stateRoot),
+			LastResultsHash:    upperHex(tmHash([]byte("results"), parent.hash)),
+			EvidenceHash:       upperHex(tmHash()),
+			ProposerAddress:    upperHex(mockValidatorAddress()),
+		},
+		Data:       BlockData{Txs: txs},
+		Evidence:   EvidenceData{Evidence: []map[string]interface{}{}},
+		LastCommit: commit,
+	}
+}
+
+// txResult returns indexed transaction with events that Ethermint emits for Ethereum transactions.
+func (t mockTendermint) txResult(b *mockBlock, index int, prove bool) ResultTx {
+	tx, r := b.txs[index], b.receipts[index]
+	hash := upperHex(tmHash(tx.raw))
+
+	ethereumTx := []EventAttribute{
+		{Key: "amount", Value: tx.value.String(), Index: true},
+		{Key: "ethereumTxHash", Value: hexBytes(tx.hash), Index: true},
+		{Key: "txIndex", Value: strconv.Itoa(index), Index: true},
+		{Key: "txGasUsed", Value: strconv.FormatUint(r.gasUsed, 10), Index: true},
+		{Key: "txHash", Value: string(hash), Index: true},
+	}
+
+	if tx.to != nil {
+		ethereumTx = append(ethereumTx, EventAttribute{Key: "recipient", Value: hexBytes(tx.to), Index: true})
+	}
+
+	if r.status == 0 {
+		ethereumTx = append(ethereumTx, EventAttribute{Key: "ethereumTxFailed", Value: "execution reverted", Index: true})
+	}
+
+	result := ResultTx{
+		Hash:   hash,
+		Height: Int64(strconv.FormatUint(b.number, 10)),
+		Index:  uint32(index),
+		TxResult: TxResult{
+			Log:       "[]",
+			GasWanted: Int64(strconv.FormatUint(tx.gas, 10)),
+			GasUsed:   Int64(strconv.FormatUint(r.gasUsed, 10)),
+			Events: []Event{
+				{Type: "ethereum_tx", Attributes: ethereumTx},
+				{Type: "message", Attributes: []EventAttribute{
+					{Key: "action", Value: "/ethermint.evm.v1.MsgEthereumTx", Index: true},
+					{Key: "sender", Value: hexBytes(tx.from), Index: true},
+					{Key: "module", Value: "evm", Index: true},
+				}},
+			},
+		},
+		Tx:    base64Bytes(tx.raw),
+		Proof: TxProof{Proof: MerkleProof{Total: "0", Index: "0"}},
+	}
+
+	// Blocks have a single transaction, so the leaf is the root and there are no aunts.
+	if prove {
+		data, leaf := base64Bytes(tx.raw), base64Bytes(dataHash(b))
+		result.Proof = TxProof{
+			RootHash: upperHex(dataHash(b)),
+			Data:     &data,
+			Proof:    MerkleProof{Total: "1", Index: "0", LeafHash: &leaf, Aunts: []Base64{}},
+		}
+	}
+
+	return result
+}
+
+func (t mockTendermint) chainID() string {
+	return fmt.Sprintf("mock_%s-1", t.n.chainID)
+}
+
+// int64 decodes an optional decimal string param.
+func (p mockParams) int64(i int, def int64) (int64, error) {
+	var s Int64
+
+	if ok, err := p.optional(i, &s); err != nil || !ok {
+		return def, err
+	}
+
+	v, err := strconv.ParseInt(string(s), 10, 64)
+	if err != nil {
+		return 0, invalidParams("invalid argument %d: %v", i, err)
+	}
+
+	return v, nil
+}
+
+func blockID(b *mockBlock) BlockID {
+	return BlockID{Hash: upperHex(b.hash), Parts: PartSetHeader{Total: 1, Hash: upperHex(tmHash(b.hash))}}
+}
+
+func blockTime(b *mockBlock) time.Time {
+	return time.Unix(int64(b.timestamp), 0).UTC()
+}
+
+// dataHash returns Merkle root of block transaction hashes as in RFC 6962, blocks have a single transaction.
+func dataHash(b *mockBlock) []byte {
+	if len(b.txs) == 0 {
+		return tmHash()
+	}
+
+	return tmHash([]byte{0}, tmHash(b.txs[0].raw))
+}
+
+// mockValidatorKey returns ed25519 public key of the only validator.
+func mockValidatorKey() []byte {
+	return tmHash([]byte("mock validator"))
+}
+
+func mockValidatorAddress() []byte {
+	return tmHash(mockValidatorKey())[:20]
+}
+
+// tmHash returns SHA-256 hash of concatenated data.
+func tmHash(data ...[]byte) []byte {
+	h := sha256.New()
+
+	for _, d := range data {
+		h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+func upperHex(b []byte) HexBytes {
+	return HexBytes(strings.ToUpper(hex.EncodeToString(b)))
+}
+
+func base64Bytes(b []byte) Base64 {
+	return Base64(base64.StdEncoding.EncodeToString(b))
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// traceConfig decodes an optional trace config param.
+func (p mockParams) traceConfig(i int) (TraceConfig, error) {
+	var cfg TraceConfig
+
+	if _, err := p.optional(i, &cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Timeout != "" {
+		if _, err := time.ParseDuration(cfg.Timeout); err != nil {
+			return cfg, invalidParams("invalid timeout: %v", err)
+		}
+	}
+
+	switch cfg.Tracer {
+	case "", CallTracer, PrestateTracer:
+		return cfg, nil
+	default:
+		return cfg, execError("tracer %s not found", cfg.Tracer)
+	}
+}
+
+func (n *MockNode) traceTransaction(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.traceConfig(1)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, ok := n.txs[hexBytes(hash)]
+	if !ok {
+		return nil, execError("transaction %s not found", hexBytes(hash))
+	}
+
+	traces, err := n.traceBlock(ref.block, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := traces[ref.index]; t.Error != "" {
+		return nil, execError("%s", t.Error)
+	}
+
+	return traces[ref.index].Result, nil
+}
+
+func (n *MockNode) traceBlockByNumber(p mockParams) (interface{}, error) {
+	var tag BlockTag
+
+	if err := p.get(0, &tag); err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.traceConfig(1)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := n.blockByTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, execError("block %s not found", tag)
+	}
+
+	return n.traceBlock(b, cfg)
+}
+
+func (n *MockNode) traceBlockByHash(p mockParams) (interface{}, error) {
+	hash, err := p.hash(0)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.traceConfig(1)
+	if err != nil {
+		return nil, err
+	}
+
+	b := n.blockByHash(hash)
+	if b == nil {
+		return nil, execError("block %s not found", hexBytes(hash))
+	}
+
+	return n.traceBlock(b, cfg)
+}
+
+// traceCall traces a call as a transaction with zero gas price on top of block state.
+func (n *MockNode) traceCall(p mockParams) (interface{}, error) {
+	var args TransactionArgs
+
+	if err := p.get(0, &args); err != nil {
+		return nil, err
+	}
+
+	from, to, value, data, err := args.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := n.paramState(p, 1, true)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.traceConfig(2)
+	if err != nil {
+		return nil, err
+	}
+
+	state = state.copy()
+	tx := &mockTx{
+		nonce:    state.get(from).nonce,
+		gasPrice: new(big.Int),
+		gas:      mockGasLimit,
+		from:     from,
+		to:       to,
+		value:    value,
+		data:     data,
+	}
+
+	if args.Gas != nil {
+		if tx.gas, err = parseUint(*args.Gas); err != nil {
+			return nil, invalidParams("invalid gas: %v", err)
+		}
+	}
+
+	if args.AccessList != nil {
+		tx.accessList = *args.AccessList
+	}
+
+	return state.trace(tx, cfg)
+}
+
+// traceBlock replays block transactions on the state of the parent block.
+func (n *MockNode) traceBlock(b *mockBlock, cfg TraceConfig) ([]TxTrace, error) {
+	if b.number == 0 {
+		return nil, execError("genesis is not traceable")
+	}
+
+	state := n.blocks[b.number-1].state.copy()
+	traces := make([]TxTrace, 0, len(b.txs))
+
+	for _, tx := range b.txs {
+		t := TxTrace{TxHash: Hash(hexBytes(tx.hash))}
+
+		trace, err := state.trace(tx, cfg)
+		if err != nil {
+			t.Error = err.Error()
+		} else {
+			t.Result = trace
+		}
+
+		traces = append(traces, t)
+	}
+
+	return traces, nil
+}
+
+// trace executes transaction with the tracer of config, state is updated as by execute.
+//
+// Contracts are not run by an EVM, so struct logs are empty and calls have no nested calls.
+func (s mockState) trace(tx *mockTx, cfg TraceConfig) (*Trace, error) {
+	pre := s.copy()
+
+	// Execution of a copy reveals return data and storage slots that receipts do not keep.
+	exec := s.copy()
+	slots := map[string][]string{}
+
+	var contract *mockAccount
+
+	if tx.to != nil && len(exec.get(tx.to).code) > 0 {
+		contract = exec.account(tx.to)
+		contract.accessed = []string{}
+	}
+
+	_, _, created, ret, _ := exec.run(tx.from, tx.to, tx.value, tx.data, tx.nonce)
+
+	r, err := s.execute(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	callee := tx.to
+
+	switch {
+	case contract != nil:
+		slots[hex.EncodeToString(tx.to)] = contract.accessed
+	case created != nil:
+		callee, ret = created, tx.data
+
+		for slot := range exec.get(created).storage {
+			slots[hex.EncodeToString(created)] = append(slots[hex.EncodeToString(created)], slot)
+		}
+
+		sort.Strings(slots[hex.EncodeToString(created)])
+	}
+
+	if r.status == 0 {
+		ret = nil
+	}
+
+	switch cfg.Tracer {
+	case CallTracer:
+		return &Trace{Call: callFrame(tx, r, callee, ret, cfg.TracerConfig)}, nil
+	case PrestateTracer:
+		touched := [][]byte{tx.from}
+		if callee != nil {
+			touched = append(touched, callee)
+		}
+
+		if cfg.TracerConfig != nil && cfg.TracerConfig.DiffMode {
+			return &Trace{PrestateDiff: prestateDiff(pre, s, touched, slots)}, nil
+		}
+
+		prestate := Prestate{}
+		for _, addr := range touched {
+			prestate[Address(hexBytes(addr))] = pre.get(addr).prestate(slots[hex.EncodeToString(addr)])
+		}
+
+		return &Trace{Prestate: prestate}, nil
+	default:
+		return &Trace{StructLogs: &StructLogTrace{
+			Gas:         r.gasUsed,
+			Failed:      r.status == 0,
+			ReturnValue: hex.EncodeToString(ret),
+			StructLogs:  []StructLog{},
+		}}, nil
+	}
+}
+
+// callFrame returns top call frame of a traced transaction.
+func callFrame(tx *mockTx, r *mockReceipt, callee, ret []byte, cfg *TracerConfig) *CallFrame {
+	value := hexBig(tx.value)
+	frame := &CallFrame{
+		Type:    "CALL",
+		From:    Address(hexBytes(tx.from)),
+		Value:   &value,
+		Gas:     hexUint(tx.gas),
+		GasUsed: hexUint(r.gasUsed),
+		Input:   Data(hexBytes(tx.data)),
+	}
+
+	if tx.to == nil {
+		frame.Type = "CREATE"
+	}
+
+	if callee != nil {
+		to := Address(hexBytes(callee))
+		frame.To = &to
+	}
+
+	if r.status == 0 {
+		frame.Error = "execution reverted"
+	} else {
+		output := Data(hexBytes(ret))
+		frame.Output = &output
+	}
+
+	if cfg != nil && cfg.WithLog {
+		for _, l := range r.logs {
+			topics := make([]Hash, 0, len(l.topics))
+			for _, t := range l.topics {
+				topics = append(topics, Hash(hexBytes(t)))
+			}
+
+			frame.Logs = append(frame.Logs, CallLog{
+				Address: Address(hexBytes(l.address)),
+				Topics:  topics,
+				Data:    Data(hexBytes(l.data)),
+			})
+		}
+	}
+
+	return frame
+}
+
+// prestateDiff returns modified accounts before and their modified fields after the transaction.
+func prestateDiff(pre, post mockState, touched [][]byte, slots map[string][]string) *PrestateDiff {
+	diff := &PrestateDiff{Pre: Prestate{}, Post: Prestate{}}
+
+	for _, addr := range touched {
+		keys := slots[hex.EncodeToString(addr)]
+		before, after := pre.get(addr).prestate(keys), post.get(addr).prestate(keys)
+		changed, modified := PrestateAccount{}, false
+
+		if *before.Balance != *after.Balance {
+			changed.Balance, modified = after.Balance, true
+		}
+
+		if before.Nonce != after.Nonce {
+			changed.Nonce, modified = after.Nonce, true
+		}
+
+		if before.Code == nil && after.Code != nil {
+			changed.Code, modified = after.Code, true
+		}
+
+		for key, value := range after.Storage {
+			if before.Storage[key] != value {
+				if changed.Storage == nil {
+					changed.Storage = map[Hash]Hash{}
+				}
+
+				changed.Storage[key], modified = value, true
+			}
+		}
+
+		if !modified {
+			continue
+		}
+
+		// Created accounts have no state before the transaction.
+		if _, existed := pre[hex.EncodeToString(addr)]; existed {
+			diff.Pre[Address(hexBytes(addr))] = before
+		}
+
+		diff.Post[Address(hexBytes(addr))] = changed
+	}
+
+	return diff
+}
+
+// prestate returns account state with values of given storage slots.
+func (a mockAccount) prestate(slots []string) PrestateAccount {
+	balance := hexBig(a.balance)
+	p := PrestateAccount{Balance: &balance, Nonce: a.nonce}
+
+	if len(a.code) > 0 {
+		code := Data(hexBytes(a.code))
+		p.Code = &code
+	}
+
+	for _, slot := range slots {
+		if p.Storage == nil {
+			p.Storage = map[Hash]Hash{}
+		}
+
+		p.Storage[Hash("0x"+slot)] = Hash(hexBytes(leftPad(a.storage[slot], 32)))
+	}
+
+	return p
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// NetworkHeader is a request header with network name.
+const NetworkHeader = "X-Network"
+
+// Network backends.
+const (
+	BackendUpstream = "upstream"
+	BackendMock     = "mock"
+)
+
+// Network is a named chain that calls are forwarded to.
+type Network struct {
+	Name    string `json:"name"`
+	ChainID int64  `json:"chainId"`
+	Backend string `json:"backend,omitempty"`
+	RPCURL  string `json:"rpcUrl,omitempty"`
+
+	// RPCURLs are more upstream nodes, calls are balanced between rpcUrl and them
+	// with round-robin or latency balancing, nodes lagging more than maxLag blocks are skipped.
+	RPCURLs   []string `json:"rpcUrls,omitempty"`
+	Balancing string   `json:"balancing,omitempty"`
+	MaxLag    *uint64  `json:"maxLag,omitempty"`
+
+	WSURL       string `json:"wsUrl,omitempty"`
+	ExplorerURL string `json:"explorerUrl,omitempty"`
+
+	// TendermintURL is an endpoint of Tendermint RPC, networks without it are not
+	// available in Tendermint docs unless they are answered by a mock node.
+	TendermintURL string `json:"tendermintUrl,omitempty"`
+
+	// Namespaces switches method namespaces on or off, e.g. debug: false,
+	// namespaces that are not listed are enabled unless they are optional.
+	Namespaces map[string]bool `json:"namespaces,omitempty"`
+
+	// Methods allow or deny methods by name patterns, denied methods are
+	// not served and not documented in specs of the network.
+	Methods MethodRules `json:"methods,omitempty"`
+
+	caller     Caller
+	subscriber Subscriber
+	tendermint Caller
+	pool       *UpstreamPool
+}
+
+// Enabled tells whether namespace of the method is enabled and the method is allowed on the network.
+func (nw *Network) Enabled(method string) bool {
+	enabled, ok := nw.Namespaces[namespace(method)]
+
+	return (!ok || enabled) && nw.Methods.Allowed(method)
+}
+
+// disabled returns sorted namespaces that are switched off on the network.
+func (nw *Network) disabled() []string {
+	var namespaces []string
+
+	for ns, enabled := range nw.Namespaces {
+		if !enabled {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
+// Networks is a list of available networks, the first one is the default.
+type Networks struct {
+	List []*Network `json:"networks"`
+}
+
+// LoadNetworks reads networks from a YAML or JSON file.
+func LoadNetworks(fileName string) (*Networks, error) {
+	data, err := ioutil.ReadFile(fileName) // nolint:gosec // File name comes from trusted configuration.
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := yamlToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse networks %s: %w", fileName, err)
+	}
+
+	var n Networks
+
+	if err := json.Unmarshal(j, &n); err != nil {
+		return nil, fmt.Errorf("failed to parse networks %s: %w", fileName, err)
+	}
+
+	if len(n.List) == 0 {
+		return nil, fmt.Errorf("no networks in %s", fileName)
+	}
+
+	seen := make(map[string]bool, len(n.List))
+
+	for _, nw := range n.List {
+		if nw.Name == "" {
+			return nil, errors.New("network name is required")
+		}
+
+		if seen[nw.Name] {
+			return nil, fmt.Errorf("duplicate network %s", nw.Name)
+		}
+
+		seen[nw.Name] = true
+
+		if err := nw.Methods.Validate(); err != nil {
+			return nil, fmt.Errorf("network %s: %w", nw.Name, err)
+		}
+
+		switch nw.Backend {
+		case "", BackendUpstream:
+			if nw.RPCURL == "" {
+				return nil, fmt.Errorf("network %s: rpcUrl is required", nw.Name)
+			}
+
+			if err := nw.setupUpstream(); err != nil {
+				return nil, fmt.Errorf("network %s: %w", nw.Name, err)
+			}
+
+			if nw.WSURL != "" {
+				nw.subscriber = &WSUpstream{URL: nw.WSURL}
+			}
+
+			if nw.TendermintURL != "" {
+				nw.tendermint = &Upstream{URL: nw.TendermintURL}
+			}
+		case BackendMock:
+			mock := NewMockNode(nw.ChainID)
+			nw.caller, nw.subscriber, nw.tendermint = mock, mock, mock.Tendermint()
+		default:
+			return nil, fmt.Errorf("network %s: unknown backend %q", nw.Name, nw.Backend)
+		}
+	}
+
+	return &n, nil
+}
+
+// setupUpstream forwards calls to the upstream node or to a pool of them.
+func (nw *Network) setupUpstream() error {
+	switch nw.Balancing {
+	case "", BalancingRoundRobin, BalancingLatency:
+	default:
+		return fmt.Errorf("unknown balancing %q, expected %s or %s", nw.Balancing, BalancingRoundRobin, BalancingLatency)
+	}
+
+	if len(nw.RPCURLs) == 0 {
+		nw.caller = &Upstream{URL: nw.RPCURL}
+
+		return nil
+	}
+
+	maxLag := uint64(defaultMaxLag)
+	if nw.MaxLag != nil {
+		maxLag = *nw.MaxLag
+	}
+
+	nw.pool = NewUpstreamPool(nw.Name, append([]string{nw.RPCURL}, nw.RPCURLs...), nw.Balancing, maxLag)
+	nw.caller = nw.pool
+
+	return nil
+}
+
+// SetTimeout limits duration of calls to upstream nodes.
+func (n *Networks) SetTimeout(timeout time.Duration) {
+	client := &http.Client{Timeout: timeout}
+
+	for _, nw := range n.List {
+		if u, ok := nw.caller.(*Upstream); ok {
+			u.Client = client
+		}
+
+		if u, ok := nw.tendermint.(*Upstream); ok {
+			u.Client = client
+		}
+
+		if nw.pool != nil {
+			for _, node := range nw.pool.Nodes {
+				node.Upstream.Client = client
+			}
+		}
+	}
+}
+
+// SetAccess classifies methods of upstream pools, so that only read methods are retried.
+func (n *Networks) SetAccess(access AccessClasses) {
+	for _, nw := range n.List {
+		if nw.pool != nil {
+			nw.pool.Access = access
+		}
+	}
+}
+
+// Mock switches every network to an in-memory mock node.
+func (n *Networks) Mock() {
+	for _, nw := range n.List {
+		if nw.Backend != BackendMock {
+			mock := NewMockNode(nw.ChainID)
+			nw.Backend = BackendMock
+			nw.caller, nw.subscriber, nw.tendermint = mock, mock, mock.Tendermint()
+			nw.pool = nil
+		}
+	}
+}
+
+// StartPools sets retries and timeouts of upstream pools and starts their health checks.
+func (n *Networks) StartPools(ctx context.Context, interval, checkTimeout, callTimeout time.Duration, retries int) {
+	pools := map[string]interface{}{}
+
+	for _, nw := range n.List {
+		if nw.pool == nil {
+			continue
+		}
+
+		nw.pool.Retries = retries
+		nw.pool.CallTimeout, nw.pool.CheckTimeout = callTimeout, checkTimeout
+		pools[nw.Name] = nw.pool.Nodes
+
+		go nw.pool.CheckHealth(ctx, interval)
+	}
+
+	if len(pools) > 0 {
+		expvar.Publish("upstreams", expvar.Func(func() interface{} { return pools }))
+	}
+}
+
+// Optional switches namespaces off on networks that do not list them.
+func (n *Networks) Optional(namespaces ...string) {
+	for _, nw := range n.List {
+		for _, ns := range namespaces {
+			if _, ok := nw.Namespaces[ns]; ok {
+				continue
+			}
+
+			if nw.Namespaces == nil {
+				nw.Namespaces = map[string]bool{}
+			}
+
+			nw.Namespaces[ns] = false
+		}
+	}
+}
+
+// Wrap replaces backend of every network with a wrapped one.
+func (n *Networks) Wrap(wrap func(nw *Network, c Caller) Caller) {
+	for _, nw := range n.List {
+		nw.caller = wrap(nw, nw.caller)
+	}
+}
+
+// WrapTendermint replaces Tendermint RPC backend of every network that has one with a wrapped one.
+func (n *Networks) WrapTendermint(wrap func(nw *Network, c Caller) Caller) {
+	for _, nw := range n.List {
+		if nw.tendermint != nil {
+			nw.tendermint = wrap(nw, nw.tendermint)
+		}
+	}
+}
+
+// uiNetwork is a network as shown by Swagger UI, upstream endpoints are not exposed.
+type uiNetwork struct {
+	Name        string      `json:"name"`
+	ChainID     int64       `json:"chainId"`
+	ExplorerURL string      `json:"explorerUrl,omitempty"`
+	Disabled    []string    `json:"disabled,omitempty"`
+	Methods     MethodRules `json:"methods,omitempty"`
+}
+
+// ui returns networks for the network selector of Swagger UI.
+func (n *Networks) ui() []uiNetwork {
+	list := make([]uiNetwork, 0, len(n.List))
+
+	for _, nw := range n.List {
+		u := uiNetwork{
+			Name:        nw.Name,
+			ChainID:     nw.ChainID,
+			ExplorerURL: nw.ExplorerURL,
+			Disabled:    nw.disabled(),
+			Methods:     nw.Methods,
+		}
+
+		list = append(list, u)
+	}
+
+	return list
+}
+
+// Tendermint returns networks with Tendermint RPC, nil if there are none.
+func (n *Networks) Tendermint() *Networks {
+	var t Networks
+
+	for _, nw := range n.List {
+		if nw.tendermint != nil {
+			t.List = append(t.List, nw)
+		}
+	}
+
+	if len(t.List) == 0 {
+		return nil
+	}
+
+	return &t
+}
+
+// TendermintCaller forwards calls to Tendermint RPC of the request network.
+type TendermintCaller struct {
+	Networks *Networks
+}
+
+// Call forwards method call to Tendermint RPC backend of the request network.
+func (t TendermintCaller) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	return t.Networks.NetworkFromContext(ctx).tendermint.Call(ctx, method, params)
+}
+
+// Find returns network by name, empty name stands for the default network.
+func (n *Networks) Find(name string) (*Network, bool) {
+	if name == "" {
+		return n.List[0], true
+	}
+
+	for _, nw := range n.List {
+		if nw.Name == name {
+			return nw, true
+		}
+	}
+
+	return nil, false
+}
+
+type networkCtxKey struct{}
+
+// NetworkFromContext returns network of the request, the default network if none was chosen.
+func (n *Networks) NetworkFromContext(ctx context.Context) *Network {
+	if nw, ok := ctx.Value(networkCtxKey{}).(*Network); ok {
+		return nw
+	}
+
+	return n.List[0]
+}
+
+// Call forwards method call to the backend of the request network, methods of
+// disabled namespaces are not found.
+func (n *Networks) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	if !n.Allows(ctx, method) {
+		return nil, methodNotFound(method)
+	}
+
+	return n.NetworkFromContext(ctx).caller.Call(ctx, method, params)
+}
+
+// Allows tells whether method is enabled on the request network and allowed to the request API key,
+// rpc.discover is always served.
+func (n *Networks) Allows(ctx context.Context, method string) bool {
+	return method == "rpc.discover" || n.NetworkFromContext(ctx).Enabled(method) && keyAllows(ctx, method)
+}
+
+// Middleware puts the network chosen with header or query parameter into request context.
+func (n *Networks) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(NetworkHeader)
+		if name == "" {
+			name = r.URL.Query().Get("network")
+		}
+
+		nw, found := n.Find(name)
+		if !found {
+			writeError(w, jsonrpc.CodeInvalidRequest, fmt.Errorf("unknown network: %s", name))
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), networkCtxKey{}, nw)))
+	})
+}
+
+// allowedMethods returns check of methods enabled on the network and allowed to the request API key,
+// nil if every method is allowed.
+func allowedMethods(ctx context.Context, nw *Network) func(method string) bool {
+	key := APIKeyFromContext(ctx)
+
+	if nw.Methods.Empty() && len(nw.disabled()) == 0 && (key == nil || key.Methods.Empty()) {
+		return nil
+	}
+
+	return func(method string) bool {
+		return nw.Enabled(method) && keyAllows(ctx, method)
+	}
+}
+
+// DocHandler serves API document with methods allowed on the network chosen with header or
+// query parameter and to the API key of the request.
+func (n *Networks) DocHandler(
+	doc func() ([]byte, error),
+	filter func(doc []byte, allowed func(method string) bool) ([]byte, error),
+) http.Handler {
+	return n.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := doc()
+		if allowed := allowedMethods(r.Context(), n.NetworkFromContext(r.Context())); err == nil && allowed != nil {
+			data, err = filter(data, allowed)
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+}
+
+// writeError responds with JSON-RPC error that is not related to a particular request.
+func writeError(w http.ResponseWriter, code jsonrpc.ErrorCode, err error) {
+	writeErrorStatus(w, http.StatusOK, code, err)
+}
+
+// writeErrorStatus responds with JSON-RPC error and HTTP status.
+func writeErrorStatus(w http.ResponseWriter, status int, code jsonrpc.ErrorCode, err error) {
+	w.Header().Set("Content-Type", "application/json; charset: utf-8")
+
+	data, err := json.Marshal(jsonrpc.Response{
+		JSONRPC: "2.0",
+		Error: &jsonrpc.Error{
+			Code:    code,
+			Message: err.Error(),
+		},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNetworksUI(t *testing.T) {
+	networks := &Networks{List: []*Network{
+		{
+			Name:          "mainnet",
+			ChainID:       25,
+			RPCURL:        "https://node-a.internal:8545",
+			RPCURLs:       []string{"https://node-b.internal:8545"},
+			WSURL:         "wss://node-a.internal:8546",
+			TendermintURL: "https://node-a.internal:26657",
+			ExplorerURL:   "https://explorer.example.com",
+			Namespaces:    map[string]bool{"debug": false, "txpool": false, "eth": true},
+			Methods:       MethodRules{Deny: []string{"eth_sign*"}},
+		},
+	}}
+
+	data, err := json.Marshal(networks.ui())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leak := range []string{"internal", "rpcUrl", "wsUrl", "tendermintUrl"} {
+		if strings.Contains(string(data), leak) {
+			t.Errorf("networks JSON exposes %q: %s", leak, data)
+		}
+	}
+
+	want := `[{"name":"mainnet","chainId":25,"explorerUrl":"https://explorer.example.com",` +
+		`"disabled":["debug","txpool"],"methods":{"deny":["eth_sign*"]}}]`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestDocHandler(t *testing.T) {
+	networks := &Networks{List: []*Network{
+		{Name: "mainnet"},
+		{Name: "public", Namespaces: map[string]bool{"debug": false, "cronos": false}},
+		{Name: "partner", Namespaces: map[string]bool{"debug": false}, Methods: MethodRules{Deny: []string{"eth_sign*"}}},
+	}}
+
+	doc := `{"methods":[{"name":"eth_chainId"},{"name":"eth_signTransaction"},` +
+		`{"name":"debug_traceCall"},{"name":"cronos_getTransactionReceiptsByBlock"}]}`
+
+	h := networks.DocHandler(func() ([]byte, error) {
+		return []byte(doc), nil
+	}, filterOpenRPC)
+
+	for network, want := range map[string][]string{
+		"mainnet": {"eth_chainId", "eth_signTransaction", "debug_traceCall", "cronos_getTransactionReceiptsByBlock"},
+		"public":  {"eth_chainId", "eth_signTransaction"},
+		"partner": {"eth_chainId", "cronos_getTransactionReceiptsByBlock"},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openrpc.json?network="+network, nil))
+
+		var got struct {
+			Methods []struct {
+				Name string `json:"name"`
+			} `json:"methods"`
+		}
+
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, m := range got.Methods {
+			names = append(names, m.Name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got methods %v, want %v", network, names, want)
+		}
+	}
+}
+
+func TestNetworksOptional(t *testing.T) {
+	networks := &Networks{List: []*Network{
+		{Name: "testnet", Namespaces: map[string]bool{"cronos": true}},
+		{Name: "mainnet", Namespaces: map[string]bool{"debug": false}},
+		{Name: "mock"},
+	}}
+
+	catalog, err := LoadCatalog("catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext, err := LoadCatalog("ethermint.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Namespaces of extensions are switched off on networks that do not enable them, as on startup.
+	namespaces, err := catalog.Extend(ext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(namespaces, ",") != "cronos,miner" {
+		t.Fatalf("unexpected extension namespaces %v", namespaces)
+	}
+
+	networks.Optional(namespaces...)
+
+	for _, tc := range []struct {
+		network string
+		method  string
+		enabled bool
+	}{
+		{network: "testnet", method: "cronos_getTransactionReceiptsByBlock", enabled: true},
+		{network: "testnet", method: "miner_setGasPrice"},
+		{network: "mainnet", method: "cronos_getTransactionReceiptsByBlock"},
+		{network: "mainnet", method: "debug_traceCall"},
+		{network: "mainnet", method: "eth_chainId", enabled: true},
+		{network: "mock", method: "miner_setGasPrice"},
+	} {
+		nw, _ := networks.Find(tc.network)
+
+		if got := nw.Enabled(tc.method); got != tc.enabled {
+			t.Errorf("%s %s: enabled %v, want %v", tc.network, tc.method, got, tc.enabled)
+		}
+
+		// Docs describe the same methods that are served.
+		if allowed := allowedMethods(context.Background(), nw); allowed == nil || allowed(tc.method) != tc.enabled {
+			t.Errorf("%s %s: documented unlike served", tc.network, tc.method)
+		}
+	}
+
+}
+
+func TestTendermintRules(t *testing.T) {
+	backend := callerFunc(func(context.Context, string, json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`{}`), nil
+	})
+
+	networks := &Networks{List: []*Network{
+		{Name: "mainnet", tendermint: backend},
+		{Name: "partner", tendermint: backend, Methods: MethodRules{Deny: []string{"tx_search"}}},
+	}}
+
+	cfg := Config{
+		TendermintCatalogFile: "tendermint.yaml",
+		TendermintRPCPath:     "/tendermint",
+		TendermintDocsPath:    "/docs/tendermint",
+		TendermintSpecPath:    "/docs/tendermint/jsonrpc.json",
+		UIAssets:              "cdn",
+		ResultValidation:      ResultValidationOff,
+		BatchConcurrency:      4,
+		Methods:               MethodRules{Deny: []string{"block"}},
+	}
+
+	r := chi.NewRouter()
+	if err := mountTendermint(r, cfg, networks, loadTestKeys(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		status   = `{"jsonrpc":"2.0","method":"status","params":[],"id":1}`
+		block    = `{"jsonrpc":"2.0","method":"block","params":[null],"id":1}`
+		txSearch = `{"jsonrpc":"2.0","method":"tx_search","params":["tx.height=5",null,null,null,null],"id":1}`
+		notFound = `"code":-32601`
+		result   = `"result":{}`
+	)
+
+	for _, tc := range []struct {
+		name, network, key, body, want string
+	}{
+		{name: "allowed", network: "mainnet", key: "team-key", body: txSearch, want: result},
+		{name: "denied on every network", network: "mainnet", key: "team-key", body: block, want: notFound},
+		{name: "denied on network", network: "partner", key: "team-key", body: txSearch, want: notFound},
+		{name: "allowed on network", network: "partner", key: "team-key", body: status, want: result},
+		{name: "denied to key", network: "mainnet", key: "partner-key", body: status, want: notFound},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tendermint?network="+tc.network, strings.NewReader(tc.body))
+		req.Header.Set(APIKeyHeader, tc.key)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.name, rec.Body.String(), tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		network, key string
+		want         []string
+	}{
+		{network: "mainnet", key: "team-key", want: []string{"status", "tx_search"}},
+		{network: "partner", key: "team-key", want: []string{"status"}},
+		{network: "mainnet", key: "partner-key"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/docs/tendermint/jsonrpc.json?network="+tc.network, nil)
+		req.Header.Set(APIKeyHeader, tc.key)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var spec struct {
+			Paths map[string]json.RawMessage `json:"paths"`
+		}
+
+		if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, m := range []string{"status", "block", "tx_search"} {
+			want := false
+
+			for _, w := range tc.want {
+				want = want || w == m
+			}
+
+			if _, got := spec.Paths[m]; got != want {
+				t.Errorf("%s with %s: %s documented %v, want %v", tc.network, tc.key, m, got, want)
+			}
+		}
+	}
+}
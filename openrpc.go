@@ -0,0 +1,601 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/swaggest/openapi-go/openapi3"
+	"github.com/swaggest/usecase"
+)
+
+// openRPCDocument is a subset of OpenRPC 1.x document used to import methods.
+type openRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       CatalogInfo       `json:"info"`
+	Methods    []json.RawMessage `json:"methods"`
+	Components struct {
+		Schemas            map[string]json.RawMessage `json:"schemas"`
+		ContentDescriptors map[string]json.RawMessage `json:"contentDescriptors"`
+		Examples           map[string]json.RawMessage `json:"examples"`
+		ExamplePairings    map[string]json.RawMessage `json:"examplePairingObjects"`
+		Tags               map[string]json.RawMessage `json:"tags"`
+	} `json:"components"`
+}
+
+type openRPCMethod struct {
+	Name        string            `json:"name"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	Tags        []json.RawMessage `json:"tags"`
+	Params      []json.RawMessage `json:"params"`
+	Result      json.RawMessage   `json:"result"`
+	Examples    []json.RawMessage `json:"examples"`
+	Access      string            `json:"x-access"`
+}
+
+type openRPCContentDescriptor struct {
+	Name        string      `json:"name"`
+	Summary     string      `json:"summary"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required"`
+	Schema      interface{} `json:"schema"`
+}
+
+type openRPCExamplePairing struct {
+	Name   string            `json:"name"`
+	Params []json.RawMessage `json:"params"`
+	Result json.RawMessage   `json:"result"`
+}
+
+type openRPCExample struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// openRPCRef is a reference object, e.g. {"$ref": "#/components/contentDescriptors/Block"}.
+type openRPCRef struct {
+	Ref string `json:"$ref"`
+}
+
+// importOpenRPC converts OpenRPC document into catalog.
+func importOpenRPC(data []byte) (*Catalog, error) {
+	var doc openRPCDocument
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	c := Catalog{
+		Info:    doc.Info,
+		Schemas: make(map[string]json.RawMessage, len(doc.Components.Schemas)),
+	}
+
+	for name, s := range doc.Components.Schemas {
+		schema, err := doc.schema(s)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+
+		c.Schemas[name] = schema
+	}
+
+	for _, raw := range doc.Methods {
+		var om openRPCMethod
+
+		if err := doc.resolve(raw, "", &om); err != nil {
+			return nil, err
+		}
+
+		m, err := doc.method(om)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", om.Name, err)
+		}
+
+		c.Methods = append(c.Methods, m)
+	}
+
+	return &c, nil
+}
+
+// method converts OpenRPC method to catalog method.
+func (doc *openRPCDocument) method(om openRPCMethod) (Method, error) {
+	m := Method{
+		Name:        om.Name,
+		Summary:     om.Summary,
+		Description: om.Description,
+		Access:      om.Access,
+	}
+
+	for _, raw := range om.Tags {
+		var tag struct {
+			Name string `json:"name"`
+		}
+
+		if err := doc.resolve(raw, "tags", &tag); err != nil {
+			return m, err
+		}
+
+		m.Tags = append(m.Tags, tag.Name)
+	}
+
+	if len(m.Tags) == 0 && m.Name != "" {
+		m.Tags = []string{namespaceTag(m.Name)}
+	}
+
+	for _, raw := range om.Params {
+		p, err := doc.contentDescriptor(raw)
+		if err != nil {
+			return m, err
+		}
+
+		m.Params = append(m.Params, p)
+	}
+
+	if len(om.Result) != 0 && string(om.Result) != "null" {
+		r, err := doc.contentDescriptor(om.Result)
+		if err != nil {
+			return m, err
+		}
+
+		m.Result = &r
+	}
+
+	for _, raw := range om.Examples {
+		ex, err := doc.example(raw)
+		if err != nil {
+			return m, err
+		}
+
+		m.Examples = append(m.Examples, ex)
+	}
+
+	return m, nil
+}
+
+// contentDescriptor converts OpenRPC content descriptor (or its reference).
+func (doc *openRPCDocument) contentDescriptor(raw json.RawMessage) (ContentDescriptor, error) {
+	var ocd openRPCContentDescriptor
+
+	if err := doc.resolve(raw, "contentDescriptors", &ocd); err != nil {
+		return ContentDescriptor{}, err
+	}
+
+	cd := ContentDescriptor{
+		Name:        ocd.Name,
+		Description: ocd.Description,
+		Required:    ocd.Required,
+	}
+
+	if cd.Description == "" {
+		cd.Description = ocd.Summary
+	}
+
+	s, err := json.Marshal(openAPISchema(ocd.Schema))
+	if err != nil {
+		return cd, err
+	}
+
+	cd.Schema = s
+
+	return cd, nil
+}
+
+// example converts OpenRPC example pairing object (or its reference).
+func (doc *openRPCDocument) example(raw json.RawMessage) (Example, error) {
+	var (
+		op openRPCExamplePairing
+		ex Example
+	)
+
+	if err := doc.resolve(raw, "examplePairingObjects", &op); err != nil {
+		return ex, err
+	}
+
+	ex.Name = op.Name
+	ex.Params = make([]json.RawMessage, 0, len(op.Params))
+
+	for _, p := range op.Params {
+		var v openRPCExample
+
+		if err := doc.resolve(p, "examples", &v); err != nil {
+			return ex, err
+		}
+
+		ex.Params = append(ex.Params, v.Value)
+	}
+
+	if len(op.Result) != 0 && string(op.Result) != "null" {
+		var v openRPCExample
+
+		if err := doc.resolve(op.Result, "examples", &v); err != nil {
+			return ex, err
+		}
+
+		ex.Result = v.Value
+	}
+
+	return ex, nil
+}
+
+// schema converts JSON Schema of OpenRPC component to OpenAPI schema.
+func (doc *openRPCDocument) schema(raw json.RawMessage) (json.RawMessage, error) {
+	var s interface{}
+
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(openAPISchema(s))
+}
+
+// resolve decodes value, following reference to document components of a kind.
+func (doc *openRPCDocument) resolve(raw json.RawMessage, kind string, v interface{}) error {
+	var ref openRPCRef
+
+	if err := json.Unmarshal(raw, &ref); err == nil && ref.Ref != "" {
+		prefix := "#/components/" + kind + "/"
+		if kind == "" || !strings.HasPrefix(ref.Ref, prefix) {
+			return fmt.Errorf("unsupported reference %s", ref.Ref)
+		}
+
+		var components map[string]json.RawMessage
+
+		switch kind {
+		case "contentDescriptors":
+			components = doc.Components.ContentDescriptors
+		case "examples":
+			components = doc.Components.Examples
+		case "examplePairingObjects":
+			components = doc.Components.ExamplePairings
+		case "tags":
+			components = doc.Components.Tags
+		}
+
+		resolved, ok := components[strings.TrimPrefix(ref.Ref, prefix)]
+		if !ok {
+			return fmt.Errorf("unresolved reference %s", ref.Ref)
+		}
+
+		raw = resolved
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// namespace returns namespace prefix of a method, e.g. "eth" for eth_call.
+func namespace(method string) string {
+	if i := strings.Index(method, "_"); i > 0 {
+		return method[:i]
+	}
+
+	return method
+}
+
+// namespaceTag returns tag of a method by its namespace prefix, e.g. "ETH Methods" for eth_call.
+func namespaceTag(method string) string {
+	ns := namespace(method)
+
+	switch ns {
+	case "eth":
+		return "ETH Methods"
+	case "txpool":
+		return "TxPool Methods"
+	default:
+		return strings.ToUpper(ns[:1]) + ns[1:] + " Methods"
+	}
+}
+
+// openAPISchemaKeywords lists JSON Schema keywords that are valid in OpenAPI 3.0 schema.
+var openAPISchemaKeywords = map[string]bool{
+	"title": true, "multipleOf": true, "maximum": true, "exclusiveMaximum": true, "minimum": true,
+	"exclusiveMinimum": true, "maxLength": true, "minLength": true, "pattern": true, "maxItems": true,
+	"minItems": true, "uniqueItems": true, "maxProperties": true, "minProperties": true, "required": true,
+	"enum": true, "type": true, "not": true, "allOf": true, "oneOf": true, "anyOf": true, "items": true,
+	"properties": true, "additionalProperties": true, "description": true, "format": true, "default": true,
+	"nullable": true, "readOnly": true, "writeOnly": true, "example": true, "deprecated": true,
+}
+
+// openAPISchema converts decoded JSON Schema to OpenAPI 3.0 schema, unsupported keywords are dropped.
+func openAPISchema(v interface{}) interface{} {
+	switch v {
+	case true:
+		return map[string]interface{}{}
+	case false:
+		return map[string]interface{}{"not": map[string]interface{}{}}
+	}
+
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	if ref, ok := s["$ref"]; ok {
+		return map[string]interface{}{"$ref": ref}
+	}
+
+	res := make(map[string]interface{}, len(s))
+
+	for k, val := range s {
+		switch {
+		case strings.HasPrefix(k, "x-"):
+			res[k] = val
+		case k == "const":
+			res["enum"] = []interface{}{val}
+		case k == "examples":
+			if ex, ok := val.([]interface{}); ok && len(ex) > 0 {
+				res["example"] = ex[0]
+			}
+		case openAPISchemaKeywords[k]:
+			res[k] = val
+		}
+	}
+
+	// Draft 6+ numeric exclusive limits.
+	for _, k := range []string{"Minimum", "Maximum"} {
+		if limit, ok := res["exclusive"+k].(float64); ok {
+			res[strings.ToLower(k)] = limit
+			res["exclusive"+k] = true
+		}
+	}
+
+	if res["type"] == "null" {
+		res["type"] = []interface{}{"null"}
+		res["enum"] = []interface{}{nil}
+	}
+
+	if types, ok := res["type"].([]interface{}); ok {
+		delete(res, "type")
+
+		var anyOf []interface{}
+
+		for _, t := range types {
+			if t == "null" {
+				res["nullable"] = true
+			} else {
+				anyOf = append(anyOf, map[string]interface{}{"type": t})
+			}
+		}
+
+		if len(anyOf) == 1 {
+			res["type"] = anyOf[0].(map[string]interface{})["type"]
+		} else if len(anyOf) > 1 {
+			res["anyOf"] = anyOf
+		}
+	}
+
+	for _, k := range []string{"not", "additionalProperties"} {
+		if _, ok := res[k].(map[string]interface{}); ok {
+			res[k] = openAPISchema(res[k])
+		}
+	}
+
+	// Tuple items are described as any of item schemas.
+	switch items := res["items"].(type) {
+	case map[string]interface{}:
+		res["items"] = openAPISchema(items)
+	case []interface{}:
+		res["items"] = map[string]interface{}{"anyOf": openAPISchemas(items)}
+	}
+
+	for _, k := range []string{"allOf", "oneOf", "anyOf"} {
+		if list, ok := res[k].([]interface{}); ok {
+			res[k] = openAPISchemas(list)
+		}
+	}
+
+	if props, ok := res["properties"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(props))
+
+		for name, p := range props {
+			converted[name] = openAPISchema(p)
+		}
+
+		res["properties"] = converted
+	}
+
+	return res
+}
+
+func openAPISchemas(list []interface{}) []interface{} {
+	res := make([]interface{}, 0, len(list))
+
+	for _, s := range list {
+		res = append(res, openAPISchema(s))
+	}
+
+	return res
+}
+
+// OpenRPC exports catalog methods as OpenRPC 1.2 document, referenced schemas are taken from spec components.
+func (c *Catalog) OpenRPC(spec *openapi3.Spec) (json.RawMessage, error) {
+	components := map[string]interface{}{}
+	methods := make([]interface{}, 0, len(c.Methods))
+
+	for _, m := range c.Methods {
+		params := make([]interface{}, 0, len(m.Params))
+
+		for _, p := range m.Params {
+			cd, err := p.openRPC(spec, components)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.Name, err)
+			}
+
+			params = append(params, cd)
+		}
+
+		result := map[string]interface{}{"name": "result", "schema": map[string]interface{}{}}
+
+		if m.Result != nil {
+			cd, err := m.Result.openRPC(spec, components)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", m.Name, err)
+			}
+
+			result = cd
+		}
+
+		method := map[string]interface{}{
+			"name":           m.Name,
+			"summary":        m.Summary,
+			"params":         params,
+			"result":         result,
+			"paramStructure": "by-position",
+			"x-access":       m.access(),
+		}
+
+		if m.Description != "" {
+			method["description"] = m.Description
+		}
+
+		if len(m.Tags) > 0 {
+			tags := make([]interface{}, 0, len(m.Tags))
+			for _, t := range m.Tags {
+				tags = append(tags, map[string]interface{}{"name": t})
+			}
+
+			method["tags"] = tags
+		}
+
+		if len(m.Examples) > 0 {
+			method["examples"] = m.openRPCExamples()
+		}
+
+		methods = append(methods, method)
+	}
+
+	info := map[string]interface{}{"title": c.Info.Title, "version": c.Info.Version}
+	if c.Info.Description != "" {
+		info["description"] = c.Info.Description
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"openrpc":    "1.2.6",
+		"info":       info,
+		"methods":    methods,
+		"components": map[string]interface{}{"schemas": components},
+	})
+}
+
+// openRPC converts content descriptor to OpenRPC with JSON Schema.
+func (cd ContentDescriptor) openRPC(spec *openapi3.Spec, components map[string]interface{}) (map[string]interface{}, error) {
+	schema, err := cd.schemaOrRef()
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]interface{}{
+		"name":   cd.Name,
+		"schema": openRPCSchema(schema, spec, components),
+	}
+
+	if cd.Description != "" {
+		res["description"] = cd.Description
+	}
+
+	if cd.Required {
+		res["required"] = true
+	}
+
+	return res, nil
+}
+
+// openRPCSchema converts OpenAPI schema to JSON Schema keeping root reference to a component.
+func openRPCSchema(s openapi3.SchemaOrRef, spec *openapi3.Spec, components map[string]interface{}) interface{} {
+	if s.SchemaReference == nil || !strings.HasPrefix(s.SchemaReference.Ref, "#/components/schemas/") {
+		return jsonSchema(s, spec, components)
+	}
+
+	name := strings.TrimPrefix(s.SchemaReference.Ref, "#/components/schemas/")
+
+	if _, ok := components[name]; !ok && spec.Components != nil && spec.Components.Schemas != nil {
+		if target, ok := spec.Components.Schemas.MapOfSchemaOrRefValues[name]; ok {
+			components[name] = jsonSchema(target, spec, components)
+		}
+	}
+
+	return map[string]interface{}{"$ref": s.SchemaReference.Ref}
+}
+
+// openRPCExamples converts method examples to OpenRPC example pairing objects.
+func (m Method) openRPCExamples() []interface{} {
+	res := make([]interface{}, 0, len(m.Examples))
+
+	for i, ex := range m.Examples {
+		name := ex.name(i)
+		params := make([]interface{}, 0, len(ex.Params))
+
+		for j, p := range ex.Params {
+			paramName := fmt.Sprintf("param%d", j+1)
+			if j < len(m.Params) {
+				paramName = m.Params[j].Name
+			}
+
+			params = append(params, map[string]interface{}{"name": paramName, "value": p})
+		}
+
+		pairing := map[string]interface{}{"name": name, "params": params}
+
+		if len(ex.Result) != 0 {
+			resultName := "result"
+			if m.Result != nil {
+				resultName = m.Result.Name
+			}
+
+			pairing["result"] = map[string]interface{}{"name": resultName, "value": ex.Result}
+		}
+
+		res = append(res, pairing)
+	}
+
+	return res
+}
+
+// discover returns rpc.discover use case that responds with OpenRPC document.
+func discover(doc json.RawMessage, networks *Networks) usecase.IOInteractor {
+	u := usecase.NewIOI(nil, new(json.RawMessage), func(ctx context.Context, input, output interface{}) error {
+		out, ok := output.(*json.RawMessage)
+		if !ok {
+			return fmt.Errorf("unexpected rpc.discover output type %T", output)
+		}
+
+		*out = doc
+
+		// Methods that are not allowed on the network or to the API key are not described.
+		if allowed := allowedMethods(ctx, networks.NetworkFromContext(ctx)); allowed != nil {
+			filtered, err := filterOpenRPC(doc, allowed)
+			if err != nil {
+				return err
+			}
+
+			*out = filtered
+		}
+
+		return nil
+	})
+
+	u.SetName("rpc.discover")
+	u.SetTags("RPC Methods")
+	u.SetTitle("Returns an OpenRPC schema as a description of this service.")
+
+	return u
+}
+
+// setupDiscoverOperation documents rpc.discover response.
+func setupDiscoverOperation(op *openapi3.Operation) error {
+	op.RequestBody = nil
+
+	resp := openapi3.Response{Description: "OpenRPC document."}
+	resp.WithContentItem("application/json", openapi3.MediaType{
+		Schema: &openapi3.SchemaOrRef{Schema: (&openapi3.Schema{}).WithType(openapi3.SchemaTypeObject)},
+	})
+
+	op.Responses.WithMapOfResponseOrRefValuesItem(strconv.Itoa(http.StatusOK), openapi3.ResponseOrRef{
+		Response: &resp,
+	})
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/swaggest/jsonrpc"
+)
+
+// executionAPIs is a small document in the style of ethereum/execution-apis.
+const executionAPIs = `{
+  "openrpc": "1.2.6",
+  "info": {"title": "Execution API", "version": "1.0.0"},
+  "methods": [
+    {
+      "name": "eth_getBlockByHash",
+      "summary": "Returns information of the block matching the given block hash.",
+      "params": [
+        {"name": "Block hash", "required": true, "schema": {"$ref": "#/components/schemas/hash32"}},
+        {"$ref": "#/components/contentDescriptors/HydratedTransactions"}
+      ],
+      "result": {
+        "name": "Block information",
+        "schema": {"oneOf": [{"$ref": "#/components/schemas/BlockObject"}, {"type": "null"}]}
+      }
+    },
+    {
+      "name": "eth_feeHistory",
+      "params": [
+        {"name": "range", "required": true, "schema": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}}
+      ],
+      "result": {"name": "Base fee", "schema": {"type": ["string", "null"]}}
+    }
+  ],
+  "components": {
+    "schemas": {
+      "hash32": {"title": "32 byte hex value", "type": "string", "pattern": "^0x[0-9a-f]{64}$"},
+      "BlockObject": {
+        "type": "object",
+        "required": ["hash"],
+        "properties": {
+          "hash": {"$ref": "#/components/schemas/hash32"},
+          "baseFeePerGas": {"type": ["string", "null"]},
+          "kind": {"const": "block"}
+        }
+      }
+    },
+    "contentDescriptors": {
+      "HydratedTransactions": {"name": "Hydrated transactions", "required": true, "schema": {"type": "boolean"}}
+    }
+  }
+}`
+
+// decodeJSON unmarshals raw JSON into generic values.
+func decodeJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+
+	var v interface{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestImportOpenRPC(t *testing.T) {
+	catalog, err := parseCatalog([]byte(executionAPIs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		got  json.RawMessage
+		want string
+	}{
+		{
+			name: "component with nullable type, const and reference",
+			got:  catalog.Schemas["BlockObject"],
+			want: `{"type":"object","required":["hash"],"properties":{` +
+				`"hash":{"$ref":"#/components/schemas/hash32"},` +
+				`"baseFeePerGas":{"type":"string","nullable":true},` +
+				`"kind":{"enum":["block"]}}}`,
+		},
+		{
+			name: "referenced content descriptor",
+			got:  catalog.Methods[0].Params[1].Schema,
+			want: `{"type":"boolean"}`,
+		},
+		{
+			name: "null result branch",
+			got:  catalog.Methods[0].Result.Schema,
+			want: `{"oneOf":[{"$ref":"#/components/schemas/BlockObject"},{"nullable":true,"enum":[null]}]}`,
+		},
+		{
+			name: "tuple items",
+			got:  catalog.Methods[1].Params[0].Schema,
+			want: `{"type":"array","items":{"anyOf":[{"type":"string"},{"type":"integer"}]}}`,
+		},
+		{
+			name: "nullable result",
+			got:  catalog.Methods[1].Result.Schema,
+			want: `{"type":"string","nullable":true}`,
+		},
+	} {
+		if got, want := decodeJSON(t, tc.got), decodeJSON(t, []byte(tc.want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %s, want %s", tc.name, tc.got, tc.want)
+		}
+	}
+
+	if tags := catalog.Methods[1].Tags; len(tags) != 1 || tags[0] != "ETH Methods" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+
+	h, err := newHandler(catalog, callerFunc(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage("null"), nil
+	}), &jsonrpc.JSONSchemaValidator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := json.Marshal(h.OpenAPI.Reflector().Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Paths map[string]struct {
+			Post struct {
+				RequestBody struct {
+					Content map[string]struct {
+						Schema json.RawMessage `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+				Responses map[string]struct {
+					Content map[string]struct {
+						Schema json.RawMessage `json:"schema"`
+					} `json:"content"`
+				} `json:"responses"`
+			} `json:"post"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"hash32", "BlockObject"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("missing component %s", name)
+		}
+	}
+
+	op := doc.Paths["eth_getBlockByHash"].Post
+	if s := string(op.RequestBody.Content["application/json"].Schema); !strings.Contains(s, `"$ref":"#/components/schemas/hash32"`) {
+		t.Errorf("unexpected params schema %s", s)
+	}
+
+	if s := string(op.Responses["200"].Content["application/json"].Schema); !strings.Contains(s, `"$ref":"#/components/schemas/BlockObject"`) {
+		t.Errorf("unexpected result schema %s", s)
+	}
+
+	// References resolve to components when params are validated.
+	rpc := BatchHandler{Handler: Passthrough{Handler: h}}
+	hash := `"0x` + strings.Repeat("ab", 32) + `"`
+
+	for params, want := range map[string]string{
+		`[` + hash + `,false]`: `"result":null`,
+		`["0x01",false]`:       `"code":-32602`,
+		`[` + hash + `,"yes"]`: `"code":-32602`,
+	} {
+		resp := postRPC(t, rpc, `{"jsonrpc":"2.0","method":"eth_getBlockByHash","params":`+params+`,"id":1}`)
+		if !strings.Contains(resp, want) {
+			t.Errorf("%s: got %s, want %s", params, resp, want)
+		}
+	}
+}
+
+func TestOpenRPCDiscover(t *testing.T) {
+	keys := loadTestKeys(t)
+
+	catalog, err := LoadCatalog("catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	networks := &Networks{List: []*Network{
+		{Name: "mainnet"},
+		{Name: "public", Namespaces: map[string]bool{"debug": false}},
+		{Name: "partner", Methods: MethodRules{Deny: []string{"eth_sign*"}}},
+	}}
+
+	h, err := newHandler(catalog, networks, &jsonrpc.JSONSchemaValidator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	openRPC, err := catalog.OpenRPC(h.OpenAPI.Reflector().Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Add(discover(openRPC, networks))
+
+	// Routes are served the way main does.
+	r := chi.NewRouter()
+	r.Mount("/rpc", keys.Middleware(true)(networks.Middleware(BatchHandler{
+		Handler: Passthrough{Handler: h, Allowed: networks.Allows},
+	})))
+	r.Method(http.MethodGet, "/openrpc.json", keys.Middleware(true)(networks.DocHandler(func() ([]byte, error) {
+		return openRPC, nil
+	}, filterOpenRPC)))
+
+	methods := func(data []byte) map[string]bool {
+		var doc struct {
+			Methods []struct {
+				Name string `json:"name"`
+			} `json:"methods"`
+		}
+
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%v: %s", err, data)
+		}
+
+		names := make(map[string]bool, len(doc.Methods))
+		for _, m := range doc.Methods {
+			names[m.Name] = true
+		}
+
+		return names
+	}
+
+	for _, tc := range []struct {
+		network, key string
+		present      []string
+		absent       []string
+	}{
+		{
+			network: "mainnet", key: "team-key",
+			present: []string{"eth_chainId", "eth_sign", "debug_traceCall", "net_version"},
+		},
+		{
+			network: "public", key: "team-key",
+			present: []string{"eth_chainId", "eth_sign"},
+			absent:  []string{"debug_traceCall"},
+		},
+		{
+			network: "partner", key: "team-key",
+			present: []string{"eth_chainId", "debug_traceCall"},
+			absent:  []string{"eth_sign"},
+		},
+		{
+			network: "mainnet", key: "partner-key",
+			present: []string{"eth_chainId", "eth_sign"},
+			absent:  []string{"debug_traceCall", "net_version"},
+		},
+		{
+			network: "partner", key: "partner-key",
+			present: []string{"eth_chainId"},
+			absent:  []string{"eth_sign", "net_version"},
+		},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/openrpc.json?network="+tc.network, nil)
+		req.Header.Set(APIKeyHeader, tc.key)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		document := methods(rec.Body.Bytes())
+
+		req = httptest.NewRequest(http.MethodPost, "/rpc?network="+tc.network,
+			strings.NewReader(`{"jsonrpc":"2.0","method":"rpc.discover","id":1}`))
+		req.Header.Set(APIKeyHeader, tc.key)
+
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+		}
+
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		discovered := methods(resp.Result)
+
+		if !reflect.DeepEqual(document, discovered) {
+			t.Errorf("%s with %s: /openrpc.json and rpc.discover describe different methods", tc.network, tc.key)
+		}
+
+		for _, m := range tc.present {
+			if !document[m] {
+				t.Errorf("%s with %s: missing %s", tc.network, tc.key, m)
+			}
+		}
+
+		for _, m := range tc.absent {
+			if document[m] {
+				t.Errorf("%s with %s: unexpected %s", tc.network, tc.key, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Upstream selection strategies.
+const (
+	BalancingRoundRobin = "round-robin"
+	BalancingLatency    = "latency"
+)
+
+// defaultMaxLag is the number of blocks that an upstream node may lag behind the highest head.
+const defaultMaxLag = 5
+
+// idempotent tells whether method only reads chain state and can be retried on another node.
+func (p *UpstreamPool) idempotent(method string) bool {
+	return p.Access.Of(method) == AccessRead
+}
+
+// UpstreamPool balances calls between upstream nodes of a network.
+//
+// Nodes are checked with eth_blockNumber, nodes that fail the check or lag behind
+// the highest head by more than MaxLag blocks are skipped until the next check.
+// Read calls that fail to reach a node, or get no response within CallTimeout, are
+// retried on other nodes.
+type UpstreamPool struct {
+	Network   string
+	Nodes     []*PoolNode
+	Balancing string
+	MaxLag    uint64
+	Retries   int
+
+	// CallTimeout limits a single attempt of a call, 0 leaves it to the upstream client.
+	CallTimeout time.Duration
+	// CheckTimeout limits health check of nodes, 0 limits it to the check interval.
+	CheckTimeout time.Duration
+	// Access classifies methods, only read methods are retried.
+	Access AccessClasses
+
+	next uint64
+}
+
+// PoolNode is an upstream node of a pool with its last health check results.
+type PoolNode struct {
+	Upstream *Upstream
+
+	mu      sync.Mutex
+	healthy bool
+	lagging bool
+	head    uint64
+	latency time.Duration
+}
+
+// NewUpstreamPool creates pool of upstream nodes, nodes are healthy until checked.
+func NewUpstreamPool(network string, urls []string, balancing string, maxLag uint64) *UpstreamPool {
+	p := &UpstreamPool{Network: network, Balancing: balancing, MaxLag: maxLag}
+
+	for _, u := range urls {
+		p.Nodes = append(p.Nodes, &PoolNode{Upstream: &Upstream{URL: u}, healthy: true})
+	}
+
+	return p
+}
+
+// Call forwards method call to an available node, read calls are retried on
+// other nodes when the node can not be reached.
+func (p *UpstreamPool) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	attempts := 1
+	if p.idempotent(method) {
+		attempts += p.Retries
+	}
+
+	var (
+		tried = map[*PoolNode]bool{}
+		err   error
+	)
+
+	for i := 0; i < attempts; i++ {
+		node := p.pick(tried)
+		if node == nil {
+			break
+		}
+
+		tried[node] = true
+
+		var result json.RawMessage
+
+		result, err = p.attempt(ctx, node, method, params)
+		if err == nil || !unreachable(ctx, err) {
+			return result, err
+		}
+
+		node.fail(p.Network, err)
+	}
+
+	return nil, err
+}
+
+// attempt calls the node within CallTimeout, a timed out attempt leaves ctx alive for a retry.
+func (p *UpstreamPool) attempt(
+	ctx context.Context, node *PoolNode, method string, params json.RawMessage,
+) (json.RawMessage, error) {
+	if p.CallTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, p.CallTimeout)
+		defer cancel()
+	}
+
+	return node.Upstream.Call(ctx, method, params)
+}
+
+// unreachable tells whether call failed to get a response from the node, errors
+// returned by the node and cancelled requests are not retried.
+func unreachable(ctx context.Context, err error) bool {
+	var upstreamErr UpstreamError
+
+	return !errors.As(err, &upstreamErr) && ctx.Err() == nil
+}
+
+// pick selects a node that was not tried yet, available nodes are preferred,
+// unavailable ones are tried when there is nothing else left.
+func (p *UpstreamPool) pick(tried map[*PoolNode]bool) *PoolNode {
+	var available, other []*PoolNode
+
+	for _, n := range p.Nodes {
+		switch {
+		case tried[n]:
+		case n.available():
+			available = append(available, n)
+		default:
+			other = append(other, n)
+		}
+	}
+
+	if len(available) == 0 {
+		available = other
+	}
+
+	if len(available) == 0 {
+		return nil
+	}
+
+	if p.Balancing == BalancingLatency {
+		return pickByLatency(available)
+	}
+
+	return available[atomic.AddUint64(&p.next, 1)%uint64(len(available))]
+}
+
+// pickByLatency selects a node at random with probability inversely proportional to its latency.
+func pickByLatency(nodes []*PoolNode) *PoolNode {
+	weights := make([]float64, len(nodes))
+	total := 0.0
+
+	for i, n := range nodes {
+		latency := n.lastLatency()
+		if latency < time.Millisecond {
+			latency = time.Millisecond
+		}
+
+		weights[i] = 1 / latency.Seconds()
+		total += weights[i]
+	}
+
+	r := rand.Float64() * total // nolint:gosec // Balancing needs no cryptographic randomness.
+
+	for i, w := range weights {
+		if r < w {
+			return nodes[i]
+		}
+
+		r -= w
+	}
+
+	return nodes[len(nodes)-1]
+}
+
+// CheckHealth checks nodes every interval until context is done.
+func (p *UpstreamPool) CheckHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	timeout := p.CheckTimeout
+	if timeout <= 0 || timeout > interval {
+		timeout = interval
+	}
+
+	for {
+		p.check(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check requests head of every node in parallel and marks nodes that lag behind the highest head.
+func (p *UpstreamPool) check(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	for _, n := range p.Nodes {
+		wg.Add(1)
+
+		go func(n *PoolNode) {
+			defer wg.Done()
+
+			n.check(ctx, p.Network)
+		}(n)
+	}
+
+	wg.Wait()
+
+	var highest uint64
+
+	for _, n := range p.Nodes {
+		if head, ok := n.healthyHead(); ok && head > highest {
+			highest = head
+		}
+	}
+
+	for _, n := range p.Nodes {
+		n.setLag(p.Network, highest, p.MaxLag)
+	}
+}
+
+// check requests latest block number of the node.
+func (n *PoolNode) check(ctx context.Context, network string) {
+	start := time.Now()
+
+	result, err := n.Upstream.Call(ctx, "eth_blockNumber", nil)
+	latency := time.Since(start)
+
+	var head uint64
+
+	if err == nil {
+		var q Quantity
+
+		if err = json.Unmarshal(result, &q); err == nil {
+			head, err = parseUint(q)
+		}
+	}
+
+	if err != nil {
+		n.fail(network, fmt.Errorf("health check failed: %w", err))
+
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.healthy {
+		log.Printf("upstream %s of network %s is healthy", n.Upstream.URL, network)
+	}
+
+	n.healthy, n.head = true, head
+
+	// Latency is smoothed, so that a single slow response does not divert calls.
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		n.latency = (3*n.latency + latency) / 4
+	}
+}
+
+// fail marks node unhealthy until it passes a health check.
+func (n *PoolNode) fail(network string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.healthy {
+		log.Printf("upstream %s of network %s is unhealthy: %v", n.Upstream.URL, network, err)
+	}
+
+	n.healthy = false
+}
+
+// setLag marks node lagging when its head is more than maxLag blocks behind the highest one.
+func (n *PoolNode) setLag(network string, highest, maxLag uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	lagging := n.healthy && n.head+maxLag < highest
+
+	switch {
+	case lagging && !n.lagging:
+		log.Printf("upstream %s of network %s lags %d blocks behind", n.Upstream.URL, network, highest-n.head)
+	case !lagging && n.lagging && n.healthy:
+		log.Printf("upstream %s of network %s caught up", n.Upstream.URL, network)
+	}
+
+	n.lagging = lagging
+}
+
+func (n *PoolNode) available() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.healthy && !n.lagging
+}
+
+func (n *PoolNode) healthyHead() (uint64, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.head, n.healthy
+}
+
+func (n *PoolNode) lastLatency() time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.latency
+}
+
+// MarshalJSON exposes node state for metrics.
+func (n *PoolNode) MarshalJSON() ([]byte, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return json.Marshal(map[string]interface{}{
+		"url":       n.Upstream.URL,
+		"healthy":   n.healthy,
+		"lagging":   n.lagging,
+		"head":      n.head,
+		"latencyMs": n.latency.Milliseconds(),
+	})
+}
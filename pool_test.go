@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testNode is an upstream node that answers, hangs, fails with an error or is down.
+type testNode struct {
+	behavior string
+	calls    int64
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&n.calls, 1)
+
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	switch n.behavior {
+	case "ok":
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"0x10"}`))
+	case "hang":
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	case "error":
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) +
+			`,"error":{"code":-32000,"message":"header not found"}}`))
+	}
+}
+
+// newTestPool starts nodes with behaviors, down nodes refuse connections.
+func newTestPool(t *testing.T, behaviors ...string) (*UpstreamPool, []*testNode) {
+	t.Helper()
+
+	var (
+		urls  []string
+		nodes []*testNode
+	)
+
+	for _, b := range behaviors {
+		n := &testNode{behavior: b}
+		srv := httptest.NewServer(n)
+
+		if b == "down" {
+			srv.Close()
+		} else {
+			t.Cleanup(srv.Close)
+		}
+
+		urls = append(urls, srv.URL)
+		nodes = append(nodes, n)
+	}
+
+	p := NewUpstreamPool("testnet", urls, BalancingRoundRobin, defaultMaxLag)
+	p.Retries = 2
+	p.CallTimeout = 50 * time.Millisecond
+	p.CheckTimeout = 50 * time.Millisecond
+
+	return p, nodes
+}
+
+func TestUpstreamPoolCall(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		nodes  []string
+		method string
+		calls  int64
+		result string
+		code   int
+	}{
+		// Round robin starts with the second node.
+		{
+			name:  "read retried on hung node",
+			nodes: []string{"ok", "hang"}, method: "eth_blockNumber",
+			calls: 2, result: `"0x10"`,
+		},
+		{
+			name:  "read retried on down node",
+			nodes: []string{"ok", "down"}, method: "eth_blockNumber",
+			result: `"0x10"`,
+		},
+		{
+			name:  "read retried on every node",
+			nodes: []string{"hang", "hang", "hang", "hang"}, method: "eth_blockNumber",
+			calls: 3,
+		},
+		{
+			name:  "write not retried",
+			nodes: []string{"ok", "hang"}, method: "eth_sendRawTransaction",
+			calls: 1,
+		},
+		{
+			name:  "write declared in catalog not retried",
+			nodes: []string{"ok", "hang"}, method: "cronos_sendBundle",
+			calls: 1,
+		},
+		{
+			name:  "node error not retried",
+			nodes: []string{"ok", "error"}, method: "eth_blockNumber",
+			calls: 1, code: -32000,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, nodes := newTestPool(t, tc.nodes...)
+			p.Access = AccessClasses{"cronos_sendBundle": AccessWrite}
+
+			start := time.Now()
+			result, err := p.Call(context.Background(), tc.method, nil)
+
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("call took %v", elapsed)
+			}
+
+			var upstreamErr UpstreamError
+
+			switch {
+			case tc.result != "":
+				if err != nil || string(result) != tc.result {
+					t.Errorf("got %s, %v, want %s", result, err, tc.result)
+				}
+			case tc.code != 0:
+				if !errors.As(err, &upstreamErr) || int(upstreamErr.Code) != tc.code {
+					t.Errorf("got error %v, want code %d", err, tc.code)
+				}
+			case err == nil:
+				t.Error("error expected")
+			}
+
+			if tc.calls > 0 {
+				var calls int64
+				for _, n := range nodes {
+					calls += atomic.LoadInt64(&n.calls)
+				}
+
+				if calls != tc.calls {
+					t.Errorf("nodes got %d calls, want %d", calls, tc.calls)
+				}
+			}
+		})
+	}
+}
+
+func TestUpstreamPoolCheck(t *testing.T) {
+	p, _ := newTestPool(t, "hang", "ok", "down")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		p.CheckHealth(ctx, time.Hour)
+		close(done)
+	}()
+
+	// The first check runs right away and is limited by CheckTimeout, not the interval.
+	deadline := time.Now().Add(time.Second)
+	for p.Nodes[0].available() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i, want := range []bool{false, true, false} {
+		if got := p.Nodes[i].available(); got != want {
+			t.Errorf("node %d available %v, want %v", i, got, want)
+		}
+	}
+
+	cancel()
+	<-done
+}
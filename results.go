@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// Result validation modes.
+const (
+	ResultValidationOff    = "off"
+	ResultValidationReport = "report"
+	ResultValidationStrict = "strict"
+)
+
+// Result validation metrics, published by expvar handler.
+var (
+	validatedResults = expvar.NewMap("validated_results")
+	invalidResults   = expvar.NewMap("invalid_results")
+)
+
+// ResultValidator checks results of network backend against method result schemas,
+// mismatches are logged and counted per network and method.
+type ResultValidator struct {
+	Next      Caller
+	Network   string
+	Validator jsonrpc.Validator
+
+	// Strict replaces invalid results with an error.
+	Strict bool
+}
+
+// Call validates result of the call.
+func (v *ResultValidator) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	result, err := v.Next.Call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	validatedResults.Add(v.Network, 1)
+
+	err = v.Validator.ValidateResult(method, result)
+	if err == nil {
+		return result, nil
+	}
+
+	invalidResults.Add(v.Network+"/"+method, 1)
+	log.Printf("invalid %s result from network %s: %s", method, v.Network, validationMessage(err))
+
+	if v.Strict {
+		return nil, InvalidResultError{Network: v.Network, Method: method, Err: err}
+	}
+
+	return result, nil
+}
+
+// InvalidResultError reports a result that does not match the method schema,
+// it does not unwrap to validation errors to avoid being reported as invalid params.
+type InvalidResultError struct {
+	Network string
+	Method  string
+	Err     error
+}
+
+// Error returns error message.
+func (e InvalidResultError) Error() string {
+	return fmt.Sprintf("invalid %s result from network %s", e.Method, e.Network)
+}
+
+// Fields exposes schema mismatches.
+func (e InvalidResultError) Fields() map[string]interface{} {
+	fields := map[string]interface{}{"network": e.Network}
+
+	var withFields jsonrpc.ErrWithFields
+	if errors.As(e.Err, &withFields) {
+		for k, v := range withFields.Fields() {
+			fields[k] = v
+		}
+	}
+
+	return fields
+}
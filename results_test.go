@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+func TestResultValidator(t *testing.T) {
+	catalog, err := LoadCatalog("catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := newHandler(catalog, nil, &jsonrpc.JSONSchemaValidator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	for _, tc := range []struct {
+		name    string
+		method  string
+		result  string
+		strict  bool
+		invalid bool
+	}{
+		{name: "block", method: "eth_getBlockByHash", result: `{"number":"0x1b4","hash":null}`},
+		{name: "null block", method: "eth_getBlockByHash", result: `null`},
+		{name: "number instead of block", method: "eth_getBlockByHash", result: `123`, invalid: true},
+		{name: "string instead of block", method: "eth_getBlockByNumber", result: `"foo"`, invalid: true},
+		{name: "array instead of receipt", method: "eth_getTransactionReceipt", result: `[1,2]`, invalid: true},
+		{name: "invalid count", method: "eth_getBlockTransactionCountByHash", result: `"0x01"`, invalid: true},
+		{name: "null count", method: "eth_getBlockTransactionCountByHash", result: `null`},
+		{name: "strict", method: "eth_getTransactionByHash", result: `123`, strict: true, invalid: true},
+		{name: "strict null", method: "eth_getTransactionByHash", result: `null`, strict: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.Reset()
+
+			network := "test-" + strings.ReplaceAll(tc.name, " ", "-")
+			v := &ResultValidator{
+				Next: callerFunc(func(context.Context, string, json.RawMessage) (json.RawMessage, error) {
+					return json.RawMessage(tc.result), nil
+				}),
+				Network:   network,
+				Validator: h.Validator,
+				Strict:    tc.strict,
+			}
+
+			result, err := v.Call(context.Background(), tc.method, json.RawMessage(`[]`))
+
+			var invalidErr InvalidResultError
+
+			switch {
+			case tc.invalid && tc.strict:
+				if !errors.As(err, &invalidErr) || invalidErr.Method != tc.method || result != nil {
+					t.Errorf("got %s, %v, want InvalidResultError", result, err)
+				}
+			case err != nil || string(result) != tc.result:
+				t.Errorf("got %s, %v, want %s", result, err, tc.result)
+			}
+
+			counted, _ := invalidResults.Get(network + "/" + tc.method).(*expvar.Int)
+			if got := counted != nil && counted.Value() == 1; got != tc.invalid {
+				t.Errorf("invalid result counted %v, want %v", got, tc.invalid)
+			}
+
+			if got := strings.Contains(logs.String(), "invalid "+tc.method+" result from network "+network); got != tc.invalid {
+				t.Errorf("invalid result logged %v, want %v: %s", got, tc.invalid, logs.String())
+			}
+
+			if validatedResults.Get(network).String() != "1" {
+				t.Errorf("validated results of %s are not counted", network)
+			}
+		})
+	}
+}
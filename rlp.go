@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// rlpItem is a decoded RLP string or list.
+type rlpItem struct {
+	isList bool
+	str    []byte
+	list   []rlpItem
+	raw    []byte
+}
+
+// rlpRaw is an already encoded RLP item.
+type rlpRaw []byte
+
+// rlpEncode encodes []byte, string, uint64, *big.Int, rlpRaw and []interface{} of those.
+func rlpEncode(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case rlpRaw:
+		return v, nil
+	case []byte:
+		if len(v) == 1 && v[0] < 0x80 {
+			return v, nil
+		}
+
+		return append(rlpHeader(0x80, len(v)), v...), nil
+	case string:
+		return rlpEncode([]byte(v))
+	case uint64:
+		return rlpEncode(new(big.Int).SetUint64(v))
+	case *big.Int:
+		if v == nil {
+			return rlpEncode([]byte{})
+		}
+
+		return rlpEncode(v.Bytes())
+	case []interface{}:
+		var payload []byte
+
+		for _, item := range v {
+			b, err := rlpEncode(item)
+			if err != nil {
+				return nil, err
+			}
+
+			payload = append(payload, b...)
+		}
+
+		return append(rlpHeader(0xc0, len(payload)), payload...), nil
+	default:
+		return nil, fmt.Errorf("rlp: unsupported value type %T", v)
+	}
+}
+
+// rlpHash returns Keccak-256 hash of RLP encoded value.
+func rlpHash(v interface{}) ([]byte, error) {
+	b, err := rlpEncode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return keccak256(b), nil
+}
+
+func rlpHeader(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+
+	sizeBytes := new(big.Int).SetInt64(int64(size)).Bytes()
+
+	return append([]byte{offset + 55 + byte(len(sizeBytes))}, sizeBytes...)
+}
+
+var errRLPTruncated = errors.New("rlp: value size exceeds available input length")
+
+// rlpDecode decodes data that contains exactly one RLP item.
+func rlpDecode(data []byte) (rlpItem, error) {
+	item, rest, err := rlpSplit(data)
+	if err != nil {
+		return item, err
+	}
+
+	if len(rest) != 0 {
+		return item, errors.New("rlp: input contains more than one value")
+	}
+
+	return item, nil
+}
+
+func rlpSplit(data []byte) (rlpItem, []byte, error) {
+	if len(data) == 0 {
+		return rlpItem{}, nil, errRLPTruncated
+	}
+
+	var (
+		item             rlpItem
+		headerSize, size int
+		b                = data[0]
+	)
+
+	switch {
+	case b < 0x80:
+		item.str = data[:1]
+		item.raw = data[:1]
+
+		return item, data[1:], nil
+	case b < 0xb8:
+		headerSize, size = 1, int(b-0x80)
+	case b < 0xc0:
+		headerSize = 1 + int(b-0xb7)
+		item.isList = false
+	case b < 0xf8:
+		headerSize, size = 1, int(b-0xc0)
+		item.isList = true
+	default:
+		headerSize = 1 + int(b-0xf7)
+		item.isList = true
+	}
+
+	if len(data) < headerSize {
+		return item, nil, errRLPTruncated
+	}
+
+	if (b >= 0xb8 && b < 0xc0) || b >= 0xf8 {
+		if headerSize > 9 {
+			return item, nil, errors.New("rlp: value size is too large")
+		}
+
+		size = int(new(big.Int).SetBytes(data[1:headerSize]).Int64())
+	}
+
+	if size < 0 || len(data)-headerSize < size {
+		return item, nil, errRLPTruncated
+	}
+
+	payload := data[headerSize : headerSize+size]
+	item.raw = data[:headerSize+size]
+
+	if !item.isList {
+		item.str = payload
+
+		return item, data[headerSize+size:], nil
+	}
+
+	for len(payload) > 0 {
+		var (
+			child rlpItem
+			err   error
+		)
+
+		child, payload, err = rlpSplit(payload)
+		if err != nil {
+			return item, nil, err
+		}
+
+		item.list = append(item.list, child)
+	}
+
+	return item, data[headerSize+size:], nil
+}
+
+// uint returns item as an unsigned integer.
+func (i rlpItem) uint() (uint64, error) {
+	if i.isList || len(i.str) > 8 {
+		return 0, errors.New("rlp: expected 64 bit unsigned integer")
+	}
+
+	return new(big.Int).SetBytes(i.str).Uint64(), nil
+}
+
+// big returns item as a big integer.
+func (i rlpItem) big() (*big.Int, error) {
+	if i.isList || len(i.str) > 32 {
+		return nil, errors.New("rlp: expected 256 bit unsigned integer")
+	}
+
+	return new(big.Int).SetBytes(i.str), nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRLPEncode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value interface{}
+		want  string
+		err   bool
+	}{
+		{name: "empty string", value: "", want: "80"},
+		{name: "single byte", value: []byte{0x0f}, want: "0f"},
+		{name: "string", value: "dog", want: "83646f67"},
+		{name: "long string", value: strings.Repeat("a", 56), want: "b838" + strings.Repeat("61", 56)},
+		{name: "zero", value: uint64(0), want: "80"},
+		{name: "integer", value: uint64(1024), want: "820400"},
+		{name: "nil big integer", value: (*big.Int)(nil), want: "80"},
+		{name: "empty list", value: []interface{}{}, want: "c0"},
+		{name: "list", value: []interface{}{"cat", "dog"}, want: "c88363617483646f67"},
+		{name: "nested list", value: []interface{}{[]interface{}{}, []interface{}{[]interface{}{}}}, want: "c3c0c1c0"},
+		{name: "raw", value: []interface{}{rlpRaw{0x83, 'd', 'o', 'g'}}, want: "c483646f67"},
+		{name: "unsupported type", value: 1, err: true},
+		{name: "unsupported type in list", value: []interface{}{"cat", 1.5}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := rlpEncode(tc.value)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %x", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hex.EncodeToString(got) != tc.want {
+				t.Errorf("got %x, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRLPDecode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		data  string
+		items int
+		err   bool
+	}{
+		{name: "string", data: "83646f67"},
+		{name: "long string", data: "b838" + strings.Repeat("61", 56)},
+		{name: "list", data: "c88363617483646f67", items: 2},
+		{name: "empty", data: "", err: true},
+		{name: "truncated string", data: "83646f", err: true},
+		{name: "truncated list", data: "c88363617483646f", err: true},
+		{name: "truncated size", data: "b9", err: true},
+		{name: "truncated item in list", data: "c283646f", err: true},
+		{name: "size too large", data: "bf" + strings.Repeat("ff", 8), err: true},
+		{name: "trailing bytes", data: "83646f6700", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			item, err := rlpDecode(data)
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(item.raw, data) || len(item.list) != tc.items {
+				t.Errorf("unexpected item %+v", item)
+			}
+		})
+	}
+}
+
+// signRawTx signs typed transaction fields, chain id is the first field.
+func signRawTx(t *testing.T, s signer, typ byte, fields []interface{}) []byte {
+	t.Helper()
+
+	unsigned, err := rlpEncode(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, sig, recID := s.sign(keccak256([]byte{typ}, unsigned))
+
+	signed, err := rlpEncode(append(fields, uint64(recID), r, sig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return append([]byte{typ}, signed...)
+}
+
+func TestDecodeRawTx(t *testing.T) {
+	s := newSigner(mockKeys[0])
+	to := bytes.Repeat([]byte{0x35}, 20)
+	key := bytes.Repeat([]byte{0x01}, 32)
+	accessList := []interface{}{[]interface{}{to, []interface{}{key}}}
+	gwei := big.NewInt(1e9)
+
+	accessListTx := signRawTx(t, s, AccessListTxType, []interface{}{
+		uint64(1), uint64(7), gwei, uint64(30000), to, uint64(1000), []byte{0xca, 0xfe}, accessList,
+	})
+	dynamicFeeTx := signRawTx(t, s, DynamicFeeTxType, []interface{}{
+		uint64(1), uint64(8), big.NewInt(2e9), big.NewInt(30e9), uint64(21000), to, uint64(1), []byte{}, []interface{}{},
+	})
+	wrongChainTx := signRawTx(t, s, DynamicFeeTxType, []interface{}{
+		uint64(5), uint64(8), big.NewInt(2e9), big.NewInt(30e9), uint64(21000), to, uint64(1), []byte{}, []interface{}{},
+	})
+
+	mustHex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return b
+	}
+
+	for _, tc := range []struct {
+		name  string
+		raw   []byte
+		typ   byte
+		from  string
+		nonce uint64
+		gas   uint64
+		err   string
+	}{
+		{
+			// Example of EIP-155.
+			name: "legacy",
+			raw: mustHex("f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000" +
+				"8025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761a" +
+				"ecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"),
+			typ:   LegacyTxType,
+			from:  "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f",
+			nonce: 9,
+			gas:   21000,
+		},
+		{
+			name:  "access list",
+			raw:   accessListTx,
+			typ:   AccessListTxType,
+			from:  hex.EncodeToString(s.address),
+			nonce: 7,
+			gas:   30000,
+		},
+		{
+			name:  "dynamic fee",
+			raw:   dynamicFeeTx,
+			typ:   DynamicFeeTxType,
+			from:  hex.EncodeToString(s.address),
+			nonce: 8,
+			gas:   21000,
+		},
+		{name: "empty", raw: nil, err: "empty transaction"},
+		{name: "not a list", raw: mustHex("8180"), err: "invalid transaction encoding"},
+		{name: "truncated", raw: accessListTx[:len(accessListTx)-1], err: "invalid transaction encoding"},
+		{name: "unknown type", raw: append([]byte{3}, dynamicFeeTx[1:]...), err: "unsupported transaction type"},
+		{name: "missing fields", raw: mustHex("c3010203"), err: "unsupported transaction type"},
+		{
+			name: "invalid v",
+			raw: mustHex("f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000" +
+				"801ea028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761a" +
+				"ecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"),
+			err: "invalid signature v value",
+		},
+		{name: "wrong chain", raw: wrongChainTx, err: "invalid chain id 5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &MockNode{chainID: big.NewInt(1)}
+
+			tx, err := n.decodeRawTx(tc.raw)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got error %v, want %q", err, tc.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tx.typ != tc.typ || hex.EncodeToString(tx.from) != tc.from || tx.nonce != tc.nonce || tx.gas != tc.gas {
+				t.Errorf("got type %d from %x nonce %d gas %d", tx.typ, tx.from, tx.nonce, tx.gas)
+			}
+
+			if !bytes.Equal(tx.to, to) {
+				t.Errorf("got recipient %x", tx.to)
+			}
+
+			if !bytes.Equal(tx.hash, keccak256(tc.raw)) {
+				t.Errorf("got hash %x", tx.hash)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// MethodRules allow or deny methods by name patterns, e.g. debug_* or eth_get*.
+//
+// A method is allowed when it matches an allow pattern, or there are no allow
+// patterns, and matches no deny pattern.
+type MethodRules struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Validate checks syntax of patterns.
+func (r MethodRules) Validate() error {
+	for _, patterns := range [][]string{r.Allow, r.Deny} {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid method pattern %q: %w", p, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Empty tells whether rules allow every method.
+func (r MethodRules) Empty() bool {
+	return len(r.Allow) == 0 && len(r.Deny) == 0
+}
+
+// Allowed tells whether rules allow the method.
+func (r MethodRules) Allowed(method string) bool {
+	if len(r.Allow) > 0 && !matchAny(r.Allow, method) {
+		return false
+	}
+
+	return !matchAny(r.Deny, method)
+}
+
+// matchAny tells whether method matches any of the validated patterns.
+func matchAny(patterns []string, method string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, method); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterOpenAPI removes operations of methods that allowed returns false for from OpenAPI spec,
+// batch and rpc.discover operations are kept.
+func filterOpenAPI(spec []byte, allowed func(method string) bool) ([]byte, error) {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+
+	for p := range paths {
+		if p != BatchOperation && p != "rpc.discover" && !allowed(p) {
+			delete(paths, p)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+// filterOpenRPC removes methods that allowed returns false for from OpenRPC document.
+func filterOpenRPC(openRPC []byte, allowed func(method string) bool) ([]byte, error) {
+	var doc map[string]interface{}
+
+	if err := json.Unmarshal(openRPC, &doc); err != nil {
+		return nil, err
+	}
+
+	methods, _ := doc["methods"].([]interface{})
+	kept := make([]interface{}, 0, len(methods))
+
+	for _, m := range methods {
+		if name, _ := m.(map[string]interface{})["name"].(string); allowed(name) {
+			kept = append(kept, m)
+		}
+	}
+
+	doc["methods"] = kept
+
+	return json.Marshal(doc)
+}
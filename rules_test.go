@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodDenied(t *testing.T) {
+	backend := callerFunc(func(context.Context, string, json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`"0x1"`), nil
+	})
+
+	networks := &Networks{List: []*Network{
+		{Name: "mainnet", caller: backend},
+		{Name: "public", caller: backend, Namespaces: map[string]bool{"debug": false}},
+		{Name: "partner", caller: backend, Methods: MethodRules{Allow: []string{"eth_*"}, Deny: []string{"eth_sign*"}}},
+	}}
+
+	rpc := newTestRPC(t, networks)
+	rpc.Handler = Passthrough{Handler: rpc.Handler.(Passthrough).Handler, Allowed: networks.Allows}
+	h := networks.Middleware(rpc)
+
+	key := &APIKey{Key: "k", Methods: MethodRules{Deny: []string{"eth_getBalance"}}}
+
+	const notFound = `{"jsonrpc":"2.0","error":{"code":-32601,` +
+		`"message":"the method %s does not exist/is not available"},"id":1}`
+
+	for _, tc := range []struct {
+		name    string
+		network string
+		key     *APIKey
+		method  string
+		params  string
+		want    string
+	}{
+		{name: "allowed", network: "mainnet", method: "eth_blockNumber", params: `[]`, want: `"0x1"`},
+		{name: "invalid params", network: "mainnet", method: "eth_blockNumber", params: `"0x1"`, want: `-32602`},
+		{name: "disabled namespace", network: "public", method: "debug_traceCall", params: `[]`},
+		{name: "network deny", network: "partner", method: "eth_signTransaction", params: `[]`},
+		{name: "not in network allow", network: "partner", method: "net_version", params: `[]`},
+		{name: "denied with invalid params", network: "partner", method: "eth_signTransaction", params: `"0x1"`},
+		{name: "key deny", network: "mainnet", key: key, method: "eth_getBalance", params: `[]`},
+		{name: "key allows", network: "mainnet", key: key, method: "eth_blockNumber", params: `[]`, want: `"0x1"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"jsonrpc":"2.0","method":"` + tc.method + `","params":` + tc.params + `,"id":1}`
+
+			r := httptest.NewRequest(http.MethodPost, "/rpc?network="+tc.network, strings.NewReader(body))
+			if tc.key != nil {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyCtxKey{}, tc.key))
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			got := strings.TrimSpace(rec.Body.String())
+
+			want := tc.want
+			if want == "" {
+				want = strings.Replace(notFound, "%s", tc.method, 1)
+			}
+
+			if !strings.Contains(got, want) {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestFilterOpenAPI(t *testing.T) {
+	nw := &Network{Namespaces: map[string]bool{"debug": false}, Methods: MethodRules{Deny: []string{"eth_sign*"}}}
+
+	spec := `{"openapi":"3.0.3","paths":{"eth_chainId":{},"eth_signTransaction":{},"debug_traceCall":{},` +
+		`"txpool_status":{},"rpc.discover":{},"` + BatchOperation + `":{}}}`
+
+	filtered, err := filterOpenAPI([]byte(spec), allowedMethods(context.Background(), nw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+
+	if err := json.Unmarshal(filtered, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{
+		"eth_chainId":         true,
+		"txpool_status":       true,
+		"rpc.discover":        true,
+		BatchOperation:        true,
+		"eth_signTransaction": false,
+		"debug_traceCall":     false,
+	} {
+		if _, got := doc.Paths[path]; got != want {
+			t.Errorf("%s: kept %v, want %v", path, got, want)
+		}
+	}
+}
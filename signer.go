@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// keccak256 returns Keccak-256 hash of concatenated data.
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+
+	for _, d := range data {
+		_, _ = h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+// signer is an account with a private key.
+type signer struct {
+	key     *secp256k1.PrivateKey
+	address []byte
+}
+
+// newSigner creates signer from hex encoded private key.
+func newSigner(hexKey string) signer {
+	b, err := hex.DecodeString(hexKey)
+	if err != nil {
+		panic(err)
+	}
+
+	key := secp256k1.PrivKeyFromBytes(b)
+
+	return signer{key: key, address: pubKeyAddress(key.PubKey())}
+}
+
+// pubKeyAddress returns address of public key.
+func pubKeyAddress(pub *secp256k1.PublicKey) []byte {
+	return keccak256(pub.SerializeUncompressed()[1:])[12:]
+}
+
+// sign signs a hash, it returns r, s and recovery id.
+func (s signer) sign(hash []byte) (*big.Int, *big.Int, byte) {
+	sig := ecdsa.SignCompact(s.key, hash, false)
+
+	return new(big.Int).SetBytes(sig[1:33]), new(big.Int).SetBytes(sig[33:]), sig[0] - 27
+}
+
+// recoverAddress returns address of a hash signer.
+func recoverAddress(hash []byte, r, s *big.Int, recID byte) ([]byte, error) {
+	if recID > 1 || r.BitLen() > 256 || s.BitLen() > 256 {
+		return nil, errors.New("invalid signature")
+	}
+
+	sig := make([]byte, 65)
+	sig[0] = 27 + recID
+	r.FillBytes(sig[1:33])
+	s.FillBytes(sig[33:])
+
+	pub, _, err := ecdsa.RecoverCompact(sig, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return pubKeyAddress(pub), nil
+}
+
+// personalHash returns hash of a message signed with eth_sign.
+func personalHash(message []byte) []byte {
+	return keccak256([]byte("\x19Ethereum Signed Message:\n"+strconv.Itoa(len(message))), message)
+}
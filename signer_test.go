@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestRecoverAddress(t *testing.T) {
+	s := newSigner(mockKeys[1])
+	hash := keccak256([]byte("hello"))
+	r, sig, recID := s.sign(hash)
+
+	for _, tc := range []struct {
+		name   string
+		hash   []byte
+		r, s   *big.Int
+		recID  byte
+		signer bool
+		err    bool
+	}{
+		{name: "signer", hash: hash, r: r, s: sig, recID: recID, signer: true},
+		{name: "other hash", hash: keccak256([]byte("world")), r: r, s: sig, recID: recID},
+		{name: "other recovery id", hash: hash, r: r, s: sig, recID: 1 - recID},
+		{name: "invalid recovery id", hash: hash, r: r, s: sig, recID: 2, err: true},
+		{name: "zero r", hash: hash, r: new(big.Int), s: sig, recID: recID, err: true},
+		{name: "oversized s", hash: hash, r: r, s: new(big.Int).Lsh(big.NewInt(1), 256), recID: recID, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			address, err := recoverAddress(tc.hash, tc.r, tc.s, tc.recID)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got %x", address)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Equal(address, s.address) != tc.signer {
+				t.Errorf("got address %x, signer %x", address, s.address)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"time"
+
+	"github.com/swaggest/jsonschema-go"
+)
+
+// Patterns of Tendermint RPC values, 64-bit integers are decimal strings,
+// hashes and addresses are upper case hex without prefix.
+const (
+	int64Pattern    = `^-?(0|[1-9][0-9]*)$`
+	hexBytesPattern = `^([0-9A-F]{2})*$`
+	base64Pattern   = `^[A-Za-z0-9+/]*={0,2}$`
+)
+
+// Int64 is a decimal encoded 64-bit integer.
+type Int64 string
+
+// PrepareJSONSchema documents integer format.
+func (Int64) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "Decimal encoded 64-bit integer.", "decimal", int64Pattern, "1000")
+}
+
+// HexBytes is upper case hex encoded binary data without 0x prefix.
+type HexBytes string
+
+// PrepareJSONSchema documents hex bytes format.
+func (HexBytes) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "Upper case hex encoded bytes without 0x prefix.", "hex-bytes", hexBytesPattern,
+		"6CC9E4E1A2F3ED8CF3CB1ABE7D9B0E2D2E3A87E3D5C6C54E8EC4DE2B0D8C6B1D")
+}
+
+// Base64 is base64 encoded binary data.
+type Base64 string
+
+// PrepareJSONSchema documents base64 format.
+func (Base64) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "Base64 encoded bytes.", "byte", base64Pattern, "3q2+7w==")
+}
+
+// ResultStatus is a status of the node.
+type ResultStatus struct {
+	NodeInfo      NodeInfo      `json:"node_info"`
+	SyncInfo      SyncInfo      `json:"sync_info"`
+	ValidatorInfo ValidatorInfo `json:"validator_info"`
+}
+
+// NodeInfo describes the node in the peer-to-peer network.
+type NodeInfo struct {
+	ProtocolVersion ProtocolVersion `json:"protocol_version"`
+	ID              string          `json:"id" description:"Node ID, lower case hex encoded address of the node key."`
+	ListenAddr      string          `json:"listen_addr" description:"Peer-to-peer listen address."`
+	Network         string          `json:"network" description:"Chain ID."`
+	Version         string          `json:"version" description:"Tendermint version."`
+	Channels        string          `json:"channels" description:"Hex encoded IDs of peer-to-peer channels."`
+	Moniker         string          `json:"moniker" description:"Name of the node."`
+	Other           NodeInfoOther   `json:"other"`
+}
+
+// ProtocolVersion lists protocol versions of the node.
+type ProtocolVersion struct {
+	P2P   Int64 `json:"p2p"`
+	Block Int64 `json:"block"`
+	App   Int64 `json:"app"`
+}
+
+// NodeInfoOther is additional node information.
+type NodeInfoOther struct {
+	TxIndex    string `json:"tx_index" enum:"on,off" description:"Whether transactions are indexed for tx_search."`
+	RPCAddress string `json:"rpc_address" description:"RPC listen address."`
+}
+
+// SyncInfo describes blocks stored by the node.
+type SyncInfo struct {
+	LatestBlockHash     HexBytes  `json:"latest_block_hash"`
+	LatestAppHash       HexBytes  `json:"latest_app_hash"`
+	LatestBlockHeight   Int64     `json:"latest_block_height"`
+	LatestBlockTime     time.Time `json:"latest_block_time"`
+	EarliestBlockHash   HexBytes  `json:"earliest_block_hash"`
+	EarliestAppHash     HexBytes  `json:"earliest_app_hash"`
+	EarliestBlockHeight Int64     `json:"earliest_block_height" description:"Height of the first stored block, greater than 1 on pruned nodes."`
+	EarliestBlockTime   time.Time `json:"earliest_block_time"`
+	CatchingUp          bool      `json:"catching_up" description:"True while the node syncs blocks from peers."`
+}
+
+// ValidatorInfo describes validator key of the node.
+type ValidatorInfo struct {
+	Address     HexBytes `json:"address"`
+	PubKey      PubKey   `json:"pub_key"`
+	VotingPower Int64    `json:"voting_power" description:"Zero when the node is not a validator."`
+}
+
+// PubKey is a typed public key.
+type PubKey struct {
+	Type  string `json:"type" example:"tendermint/PubKeyEd25519"`
+	Value Base64 `json:"value"`
+}
+
+// ResultBlock is a block with its ID.
+type ResultBlock struct {
+	BlockID BlockID         `json:"block_id"`
+	Block   TendermintBlock `json:"block"`
+}
+
+// BlockID identifies a block by hash of its header and of its parts.
+type BlockID struct {
+	Hash  HexBytes      `json:"hash"`
+	Parts PartSetHeader `json:"parts"`
+}
+
+// PartSetHeader describes parts that a block is gossiped in.
+type PartSetHeader struct {
+	Total uint32   `json:"total"`
+	Hash  HexBytes `json:"hash"`
+}
+
+// TendermintBlock is a block of the Tendermint chain, on Ethermint based chains its
+// hash is also the hash of the Ethereum block.
+type TendermintBlock struct {
+	Header     BlockHeader  `json:"header"`
+	Data       BlockData    `json:"data"`
+	Evidence   EvidenceData `json:"evidence"`
+	LastCommit Commit       `json:"last_commit"`
+}
+
+// BlockHeader is a header of a Tendermint block.
+type BlockHeader struct {
+	Version            Consensus `json:"version"`
+	ChainID            string    `json:"chain_id" example:"cronos_25-1"`
+	Height             Int64     `json:"height"`
+	Time               time.Time `json:"time"`
+	LastBlockID        BlockID   `json:"last_block_id"`
+	LastCommitHash     HexBytes  `json:"last_commit_hash"`
+	DataHash           HexBytes  `json:"data_hash" description:"Merkle root of transactions."`
+	ValidatorsHash     HexBytes  `json:"validators_hash"`
+	NextValidatorsHash HexBytes  `json:"next_validators_hash"`
+	ConsensusHash      HexBytes  `json:"consensus_hash"`
+	AppHash            HexBytes  `json:"app_hash" description:"Application state hash after the previous block."`
+	LastResultsHash    HexBytes  `json:"last_results_hash"`
+	EvidenceHash       HexBytes  `json:"evidence_hash"`
+	ProposerAddress    HexBytes  `json:"proposer_address"`
+}
+
+// Consensus lists block and application protocol versions.
+type Consensus struct {
+	Block Int64 `json:"block"`
+	App   Int64 `json:"app"`
+}
+
+// BlockData is a list of block transactions.
+type BlockData struct {
+	Txs []Base64 `json:"txs" description:"Encoded transactions, Cosmos transactions on Ethermint based chains."`
+}
+
+// EvidenceData is a list of evidence of validators misbehavior.
+type EvidenceData struct {
+	Evidence []map[string]interface{} `json:"evidence"`
+}
+
+// Commit is a set of validator signatures of a block.
+type Commit struct {
+	Height     Int64       `json:"height"`
+	Round      int32       `json:"round"`
+	BlockID    BlockID     `json:"block_id"`
+	Signatures []CommitSig `json:"signatures"`
+}
+
+// CommitSig is a validator signature of a block.
+type CommitSig struct {
+	BlockIDFlag      int       `json:"block_id_flag" minimum:"1" maximum:"3" description:"1 when the vote is absent, 2 for a commit, 3 for a nil vote."`
+	ValidatorAddress HexBytes  `json:"validator_address"`
+	Timestamp        time.Time `json:"timestamp"`
+	Signature        *Base64   `json:"signature"`
+}
+
+// ResultTxSearch is a page of transactions found by query.
+type ResultTxSearch struct {
+	Txs        []ResultTx `json:"txs"`
+	TotalCount Int64      `json:"total_count" description:"Number of found transactions on every page."`
+}
+
+// ResultTx is an indexed transaction with its result.
+type ResultTx struct {
+	Hash     HexBytes `json:"hash" description:"SHA-256 hash of the transaction."`
+	Height   Int64    `json:"height"`
+	Index    uint32   `json:"index" description:"Position of the transaction in the block."`
+	TxResult TxResult `json:"tx_result"`
+	Tx       Base64   `json:"tx"`
+	Proof    TxProof  `json:"proof" description:"Inclusion proof, empty unless requested with prove."`
+}
+
+// TxResult is a result of transaction execution.
+type TxResult struct {
+	Code      uint32  `json:"code" description:"Zero for success, failed Ethereum transactions have zero code too."`
+	Data      *Base64 `json:"data"`
+	Log       string  `json:"log"`
+	Info      string  `json:"info"`
+	GasWanted Int64   `json:"gas_wanted"`
+	GasUsed   Int64   `json:"gas_used"`
+	Events    []Event `json:"events"`
+	Codespace string  `json:"codespace"`
+}
+
+// Event is a typed set of attributes emitted by transaction execution, events are
+// searchable with tx_search queries like ethereum_tx.ethereumTxHash='0x...'.
+type Event struct {
+	Type       string           `json:"type" example:"ethereum_tx"`
+	Attributes []EventAttribute `json:"attributes"`
+}
+
+// EventAttribute is a key value pair of an event.
+type EventAttribute struct {
+	Key   string `json:"key" description:"Attribute key, base64 encoded before Tendermint v0.37."`
+	Value string `json:"value" description:"Attribute value, base64 encoded before Tendermint v0.37."`
+	Index bool   `json:"index" description:"Whether the attribute is indexed for search."`
+}
+
+// TxProof proves inclusion of a transaction into a block.
+type TxProof struct {
+	RootHash HexBytes    `json:"root_hash" description:"Data hash of the block."`
+	Data     *Base64     `json:"data"`
+	Proof    MerkleProof `json:"proof"`
+}
+
+// MerkleProof is a Merkle proof of a leaf.
+type MerkleProof struct {
+	Total    Int64    `json:"total"`
+	Index    Int64    `json:"index"`
+	LeafHash *Base64  `json:"leaf_hash"`
+	Aunts    []Base64 `json:"aunts"`
+}
+
+// tendermintSchemaTypes are named types available to Tendermint catalog schemas.
+var tendermintSchemaTypes = []interface{}{
+	Int64(""), HexBytes(""), Base64(""),
+	ResultStatus{}, ResultBlock{}, ResultTxSearch{}, ResultTx{}, TendermintBlock{}, BlockID{}, TxProof{},
+}
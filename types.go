@@ -0,0 +1,751 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/swaggest/jsonschema-go"
+	"github.com/swaggest/openapi-go/openapi3"
+)
+
+// Patterns of hex encoded values, quantities have no leading zeros.
+const (
+	addressPattern  = `^0x[0-9a-fA-F]{40}$`
+	hashPattern     = `^0x[0-9a-fA-F]{64}$`
+	quantityPattern = `^0x(0|[1-9a-fA-F][0-9a-fA-F]*)$`
+	dataPattern     = `^0x([0-9a-fA-F]{2})*$`
+	blockTagPattern = `^(latest|earliest|pending|safe|finalized|0x(0|[1-9a-fA-F][0-9a-fA-F]*))$`
+)
+
+// Address is a 20 bytes hex encoded account address.
+type Address string
+
+// PrepareJSONSchema documents address format.
+func (Address) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "20 bytes hex encoded address, mixed case addresses may be required to have a valid EIP-55 checksum.",
+		"address", addressPattern, "0x407d73d8a49eeb85d32cf465507dd71d507100c1")
+}
+
+// Hash is a 32 bytes hex encoded hash.
+type Hash string
+
+// PrepareJSONSchema documents hash format.
+func (Hash) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "32 bytes hex encoded hash.",
+		"hash", hashPattern, "0xb903239f8543d04b5dc1ba6579132b143087c68db1b2168786408fcbce568238")
+}
+
+// Quantity is a hex encoded unsigned integer.
+type Quantity string
+
+// PrepareJSONSchema documents quantity format.
+func (Quantity) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "Hex encoded unsigned integer without leading zeros.", "quantity", quantityPattern, "0x1b4")
+}
+
+// Data is hex encoded unformatted binary data.
+type Data string
+
+// PrepareJSONSchema documents data format.
+func (Data) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, "Hex encoded unformatted data, two hex digits per byte.", "data", dataPattern, "0xdeadbeef")
+}
+
+// BlockTag is a hex encoded block number or one of "latest", "earliest", "pending", "safe", "finalized".
+type BlockTag string
+
+// PrepareJSONSchema documents block tag format.
+func (BlockTag) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return stringSchema(s, `Hex encoded block number or one of "latest", "earliest", "pending", "safe", "finalized".`,
+		"block-tag", blockTagPattern, "latest")
+}
+
+// stringSchema sets up a non-nullable string schema, named types are shared as
+// definitions, so nullability of a pointer field must not leak into them.
+func stringSchema(s *jsonschema.Schema, description, format, pattern string, example interface{}) error {
+	s.Type = (&jsonschema.Type{}).WithSimpleTypes(jsonschema.String)
+	s.WithDescription(description)
+	s.WithFormat(format)
+	s.WithPattern(pattern)
+	s.WithExamples(example)
+
+	return nil
+}
+
+// schemaTypes are named types available to catalog schemas as #/components/schemas/<Name>.
+var schemaTypes = []interface{}{
+	Address(""), Hash(""), Quantity(""), Data(""), BlockTag(""),
+	Block{}, Transaction{}, TransactionArgs{}, Receipt{}, Log{},
+	FilterObject{}, FilterChanges{}, Syncing{},
+	AccessList{}, AccessListResult{}, FeeHistory{}, AccountProof{},
+	TraceConfig{}, Trace{}, TxTrace{}, StructLogTrace{}, CallFrame{}, Prestate{}, PrestateDiff{},
+	TxPoolContent{}, TxPoolInspect{}, TxPoolStatus{},
+}
+
+// addSchemaTypes reflects named types into spec components.
+func addSchemaTypes(r *openapi3.Reflector, types []interface{}) error {
+	reflectZeroValues(&r.Reflector)
+
+	prefix, err := definitionPrefix()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range types {
+		var collectErr error
+
+		_, err := r.Reflect(v,
+			jsonschema.RootRef,
+			jsonschema.DefinitionsPrefix("#/components/schemas/"),
+			jsonschema.CollectDefinitions(func(name string, schema jsonschema.Schema) {
+				refProperties(&schema)
+
+				s := openapi3.SchemaOrRef{}
+
+				if prefix != "" {
+					if schema, collectErr = trimRefPrefix(schema, prefix); collectErr != nil {
+						return
+					}
+
+					name = strings.TrimPrefix(name, prefix)
+				}
+
+				s.FromJSONSchema(schema.ToSchemaOrBool())
+
+				r.SpecEns().ComponentsEns().SchemasEns().WithMapOfSchemaOrRefValuesItem(name, s)
+			}),
+		)
+		if err == nil {
+			err = collectErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to reflect %T: %w", v, err)
+		}
+	}
+
+	return nil
+}
+
+// definitionPrefix returns prefix that reflector adds to names of types outside of main package,
+// e.g. in tests, catalogs refer to types by their names.
+func definitionPrefix() (string, error) {
+	var name string
+
+	_, err := (&jsonschema.Reflector{}).Reflect(Address(""), jsonschema.RootRef,
+		jsonschema.CollectDefinitions(func(n string, _ jsonschema.Schema) {
+			name = n
+		}),
+	)
+
+	return strings.TrimSuffix(name, "Address"), err
+}
+
+// trimRefPrefix removes prefix from names of referenced components.
+func trimRefPrefix(schema jsonschema.Schema, prefix string) (jsonschema.Schema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return schema, err
+	}
+
+	data = []byte(strings.ReplaceAll(string(data), `"#/components/schemas/`+prefix, `"#/components/schemas/`))
+
+	var trimmed jsonschema.Schema
+
+	err = json.Unmarshal(data, &trimmed)
+
+	return trimmed, err
+}
+
+// refProperties keeps descriptions of properties that reference other schemas
+// and makes pointer properties nullable, as siblings of $ref are ignored.
+func refProperties(s *jsonschema.Schema) {
+	if s.ReflectType == nil {
+		return
+	}
+
+	t := s.ReflectType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+
+		prop, ok := s.Properties[tag[0]]
+		if !ok || prop.TypeObject == nil || prop.TypeObject.Ref == nil {
+			continue
+		}
+
+		omitEmpty := len(tag) > 1 && tag[1] == "omitempty"
+		ref := jsonschema.Schema{Ref: prop.TypeObject.Ref}
+		wrapped := jsonschema.Schema{Description: prop.TypeObject.Description}
+
+		switch {
+		case f.Type.Kind() == reflect.Ptr && !omitEmpty:
+			wrapped.AnyOf = []jsonschema.SchemaOrBool{ref.ToSchemaOrBool(), jsonschema.Null.ToSchemaOrBool()}
+		case prop.TypeObject.Description != nil:
+			wrapped.AllOf = []jsonschema.SchemaOrBool{ref.ToSchemaOrBool()}
+		default:
+			continue
+		}
+
+		s.Properties[tag[0]] = wrapped.ToSchemaOrBool()
+	}
+}
+
+// reflectZeroValues maps types to their zero values, so that schema preparers are
+// also called for nil pointer fields and nullable results are dereferenced.
+func reflectZeroValues(r *jsonschema.Reflector) {
+	for _, v := range []interface{}{
+		Address(""), Hash(""), Quantity(""), Data(""), BlockTag(""),
+		Block{}, Transaction{}, Receipt{},
+		Int64(""), HexBytes(""), Base64(""),
+	} {
+		r.AddTypeMapping(v, v)
+	}
+}
+
+// Block describes a block header together with its transactions.
+type Block struct {
+	Number           *Quantity         `json:"number" description:"Block number, null when pending."`
+	Hash             *Hash             `json:"hash" description:"Block hash, null when pending."`
+	ParentHash       Hash              `json:"parentHash"`
+	Nonce            *Data             `json:"nonce" description:"Hash of the generated proof-of-work, null when pending."`
+	Sha3Uncles       Hash              `json:"sha3Uncles"`
+	LogsBloom        *Data             `json:"logsBloom" description:"Bloom filter for the logs of the block, null when pending."`
+	TransactionsRoot Hash              `json:"transactionsRoot"`
+	StateRoot        Hash              `json:"stateRoot"`
+	ReceiptsRoot     Hash              `json:"receiptsRoot"`
+	Miner            Address           `json:"miner"`
+	Difficulty       Quantity          `json:"difficulty"`
+	TotalDifficulty  Quantity          `json:"totalDifficulty"`
+	ExtraData        Data              `json:"extraData"`
+	Size             Quantity          `json:"size"`
+	GasLimit         Quantity          `json:"gasLimit"`
+	GasUsed          Quantity          `json:"gasUsed"`
+	Timestamp        Quantity          `json:"timestamp"`
+	BaseFeePerGas    *Quantity         `json:"baseFeePerGas,omitempty" description:"Base fee per gas, omitted before London."`
+	Transactions     BlockTransactions `json:"transactions"`
+	Uncles           []Hash            `json:"uncles"`
+}
+
+// BlockTransactions is a list of transaction hashes or full transaction objects.
+type BlockTransactions []TransactionOrHash
+
+// TransactionOrHash is a transaction hash or a full transaction object.
+type TransactionOrHash struct {
+	// Fields are tagged for schema reflection only, JSON encoding is custom.
+	Hash        *Hash        `json:"hash"`
+	Transaction *Transaction `json:"transaction"`
+}
+
+// MarshalJSON encodes whichever form is set.
+func (t TransactionOrHash) MarshalJSON() ([]byte, error) {
+	if t.Transaction != nil {
+		return json.Marshal(t.Transaction)
+	}
+
+	return json.Marshal(t.Hash)
+}
+
+// UnmarshalJSON decodes a hash string or a transaction object.
+func (t *TransactionOrHash) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &t.Hash)
+	}
+
+	return json.Unmarshal(data, &t.Transaction)
+}
+
+// PrepareJSONSchema documents both forms.
+func (TransactionOrHash) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return unionSchema(s, "Transaction hash, or full transaction object when requested.", "hash", "transaction")
+}
+
+// Transaction types.
+const (
+	LegacyTxType     = 0
+	AccessListTxType = 1
+	DynamicFeeTxType = 2
+)
+
+// Transaction describes a transaction included in a block or pending in the pool.
+type Transaction struct {
+	BlockHash            *Hash       `json:"blockHash" description:"Null when pending."`
+	BlockNumber          *Quantity   `json:"blockNumber" description:"Null when pending."`
+	From                 Address     `json:"from"`
+	Gas                  Quantity    `json:"gas"`
+	GasPrice             Quantity    `json:"gasPrice" description:"Effective gas price for EIP-1559 transactions."`
+	MaxFeePerGas         *Quantity   `json:"maxFeePerGas,omitempty" description:"Fee cap of EIP-1559 transactions."`
+	MaxPriorityFeePerGas *Quantity   `json:"maxPriorityFeePerGas,omitempty" description:"Tip cap of EIP-1559 transactions."`
+	Hash                 Hash        `json:"hash"`
+	Input                Data        `json:"input"`
+	Nonce                Quantity    `json:"nonce"`
+	To                   *Address    `json:"to" description:"Null for contract creation transactions."`
+	TransactionIndex     *Quantity   `json:"transactionIndex" description:"Null when pending."`
+	Value                Quantity    `json:"value"`
+	Type                 Quantity    `json:"type" description:"0x0 for legacy, 0x1 for EIP-2930 and 0x2 for EIP-1559 transactions."`
+	AccessList           *AccessList `json:"accessList,omitempty" description:"Access list of EIP-2930 and EIP-1559 transactions."`
+	ChainID              *Quantity   `json:"chainId,omitempty" description:"Omitted for legacy transactions without replay protection."`
+	V                    Quantity    `json:"v"`
+	R                    Quantity    `json:"r"`
+	S                    Quantity    `json:"s"`
+	YParity              *Quantity   `json:"yParity,omitempty" description:"Signature parity of typed transactions, same as v."`
+}
+
+// TransactionArgs describes a transaction to send or to execute as a call.
+type TransactionArgs struct {
+	From                 *Address    `json:"from,omitempty"`
+	To                   *Address    `json:"to,omitempty" description:"Omitted for contract creation."`
+	Gas                  *Quantity   `json:"gas,omitempty"`
+	GasPrice             *Quantity   `json:"gasPrice,omitempty" description:"Gas price of legacy and EIP-2930 transactions."`
+	MaxFeePerGas         *Quantity   `json:"maxFeePerGas,omitempty" description:"Fee cap of EIP-1559 transactions."`
+	MaxPriorityFeePerGas *Quantity   `json:"maxPriorityFeePerGas,omitempty" description:"Tip cap of EIP-1559 transactions."`
+	Value                *Quantity   `json:"value,omitempty"`
+	Data                 *Data       `json:"data,omitempty"`
+	Input                *Data       `json:"input,omitempty" description:"Alias of data, preferred by newer clients."`
+	Nonce                *Quantity   `json:"nonce,omitempty"`
+	Type                 *Quantity   `json:"type,omitempty" description:"Transaction type, derived from fee fields if omitted."`
+	AccessList           *AccessList `json:"accessList,omitempty" description:"Addresses and storage keys the transaction accesses, see EIP-2930."`
+	ChainID              *Quantity   `json:"chainId,omitempty"`
+}
+
+// AccessList lists addresses and storage keys a transaction accesses, see EIP-2930.
+type AccessList []AccessTuple
+
+// AccessTuple is an address and its storage keys in an access list.
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// AccessListResult is an access list generated for a transaction.
+type AccessListResult struct {
+	AccessList AccessList `json:"accessList" description:"Addresses and storage keys the transaction accesses."`
+	GasUsed    Quantity   `json:"gasUsed" description:"Gas used by the transaction with the access list."`
+	Error      string     `json:"error,omitempty" description:"Execution error, omitted on success."`
+}
+
+// FeeHistory describes base fees, gas usage and priority fees of a range of blocks.
+type FeeHistory struct {
+	OldestBlock   Quantity     `json:"oldestBlock" description:"Lowest block number of the range."`
+	BaseFeePerGas []Quantity   `json:"baseFeePerGas" description:"Base fees of the blocks and of the block after the newest one."`
+	GasUsedRatio  []float64    `json:"gasUsedRatio" description:"Ratios of gas used to gas limit of the blocks."`
+	Reward        [][]Quantity `json:"reward,omitempty" description:"Priority fees at requested percentiles of gas used in the blocks."`
+}
+
+// AccountProof is an account with Merkle proofs of its state and storage, see EIP-1186.
+type AccountProof struct {
+	Address      Address        `json:"address"`
+	AccountProof []Data         `json:"accountProof" description:"RLP encoded trie nodes from the state root to the account."`
+	Balance      Quantity       `json:"balance"`
+	CodeHash     Hash           `json:"codeHash"`
+	Nonce        Quantity       `json:"nonce"`
+	StorageHash  Hash           `json:"storageHash" description:"Storage trie root of the account."`
+	StorageProof []StorageProof `json:"storageProof"`
+}
+
+// StorageProof is a storage value with a Merkle proof from the storage root.
+type StorageProof struct {
+	Key   Hash     `json:"key"`
+	Value Quantity `json:"value"`
+	Proof []Data   `json:"proof" description:"RLP encoded trie nodes from the storage root to the value."`
+}
+
+// Receipt describes outcome of an executed transaction.
+type Receipt struct {
+	TransactionHash   Hash     `json:"transactionHash"`
+	TransactionIndex  Quantity `json:"transactionIndex"`
+	BlockHash         Hash     `json:"blockHash"`
+	BlockNumber       Quantity `json:"blockNumber"`
+	From              Address  `json:"from"`
+	To                *Address `json:"to" description:"Null for contract creation transactions."`
+	CumulativeGasUsed Quantity `json:"cumulativeGasUsed"`
+	GasUsed           Quantity `json:"gasUsed"`
+	ContractAddress   *Address `json:"contractAddress" description:"Created contract address, null if not a contract creation."`
+	Logs              []Log    `json:"logs"`
+	LogsBloom         Data     `json:"logsBloom"`
+	Status            Quantity `json:"status" description:"1 for success, 0 for failure."`
+	Type              Quantity `json:"type" description:"Type of the transaction."`
+	EffectiveGasPrice Quantity `json:"effectiveGasPrice" description:"Gas price paid per unit of gas."`
+}
+
+// Log is an event emitted by a contract.
+type Log struct {
+	Removed          bool      `json:"removed" description:"True when the log was removed due to a chain reorganization."`
+	LogIndex         *Quantity `json:"logIndex" description:"Null when pending."`
+	TransactionIndex *Quantity `json:"transactionIndex" description:"Null when pending."`
+	TransactionHash  *Hash     `json:"transactionHash" description:"Null when pending."`
+	BlockHash        *Hash     `json:"blockHash" description:"Null when pending."`
+	BlockNumber      *Quantity `json:"blockNumber" description:"Null when pending."`
+	Address          Address   `json:"address"`
+	Data             Data      `json:"data"`
+	Topics           []Hash    `json:"topics"`
+}
+
+// FilterObject selects logs by block range, emitting addresses and topics.
+type FilterObject struct {
+	FromBlock *BlockTag     `json:"fromBlock,omitempty"`
+	ToBlock   *BlockTag     `json:"toBlock,omitempty"`
+	BlockHash *Hash         `json:"blockHash,omitempty" description:"Restricts logs to a single block, excludes fromBlock and toBlock."`
+	Address   AddressList   `json:"address,omitempty"`
+	Topics    []TopicFilter `json:"topics,omitempty"`
+}
+
+// AddressList is a single address or a list of addresses.
+type AddressList []Address
+
+// UnmarshalJSON accepts a single address as well as a list.
+func (l *AddressList) UnmarshalJSON(data []byte) error {
+	var a Address
+
+	if err := json.Unmarshal(data, &a); err == nil && string(data) != "null" {
+		*l = AddressList{a}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]Address)(l))
+}
+
+// PrepareJSONSchema documents both address forms.
+func (AddressList) PrepareJSONSchema(s *jsonschema.Schema) error {
+	*s = anyOf(Address(""), []Address{})
+	s.WithDescription("Contract address or a list of addresses from which logs should originate.")
+	s.WithExamples("0x8888f1f195afa192cfee860698584c030f4c9db1")
+
+	return nil
+}
+
+// TopicFilter matches a topic position: null matches anything, a list matches any of its hashes.
+type TopicFilter []Hash
+
+// UnmarshalJSON accepts null, a single hash or a list of hashes.
+func (t *TopicFilter) UnmarshalJSON(data []byte) error {
+	var h Hash
+
+	if err := json.Unmarshal(data, &h); err == nil && string(data) != "null" {
+		*t = TopicFilter{h}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]Hash)(t))
+}
+
+// PrepareJSONSchema documents topic matching forms.
+func (TopicFilter) PrepareJSONSchema(s *jsonschema.Schema) error {
+	null := jsonschema.Schema{}
+	null.AddType(jsonschema.Null)
+
+	*s = anyOf(Hash(""), []Hash{})
+	s.AnyOf = append(s.AnyOf, null.ToSchemaOrBool())
+	s.WithDescription("Topic hash, a list of alternative hashes, or null to match any topic.")
+
+	return nil
+}
+
+// FilterChanges is a list of logs, or block or transaction hashes depending on the filter kind.
+type FilterChanges []LogOrHash
+
+// LogOrHash is a log object or a block or transaction hash.
+type LogOrHash struct {
+	// Fields are tagged for schema reflection only, JSON encoding is custom.
+	Hash *Hash `json:"hash"`
+	Log  *Log  `json:"log"`
+}
+
+// MarshalJSON encodes whichever form is set.
+func (l LogOrHash) MarshalJSON() ([]byte, error) {
+	if l.Log != nil {
+		return json.Marshal(l.Log)
+	}
+
+	return json.Marshal(l.Hash)
+}
+
+// UnmarshalJSON decodes a hash string or a log object.
+func (l *LogOrHash) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &l.Hash)
+	}
+
+	return json.Unmarshal(data, &l.Log)
+}
+
+// PrepareJSONSchema documents both forms.
+func (LogOrHash) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return unionSchema(s, "Log object for log filters, block hash for block filters, "+
+		"transaction hash for pending transaction filters.", "log", "hash")
+}
+
+// TxPoolContent lists transactions of the pool by sender address and decimal nonce.
+type TxPoolContent struct {
+	Pending map[Address]map[string]Transaction `json:"pending" description:"Transactions ready to be included in a block."`
+	Queued  map[Address]map[string]Transaction `json:"queued" description:"Transactions waiting for lower nonces."`
+}
+
+// TxPoolInspect summarizes transactions of the pool by sender address and decimal nonce.
+type TxPoolInspect struct {
+	Pending map[Address]map[string]string `json:"pending" description:"Summaries of transactions ready to be included in a block."`
+	Queued  map[Address]map[string]string `json:"queued" description:"Summaries of transactions waiting for lower nonces."`
+}
+
+// TxPoolStatus counts transactions of the pool.
+type TxPoolStatus struct {
+	Pending Quantity `json:"pending"`
+	Queued  Quantity `json:"queued"`
+}
+
+// Built-in tracers, the struct logger traces opcodes when no tracer is chosen.
+const (
+	CallTracer     = "callTracer"
+	PrestateTracer = "prestateTracer"
+)
+
+// TraceConfig chooses a tracer of debug_ trace methods and its options.
+type TraceConfig struct {
+	Tracer       string        `json:"tracer,omitempty" enum:"callTracer,prestateTracer" description:"Built-in tracer, struct logger if omitted."`
+	TracerConfig *TracerConfig `json:"tracerConfig,omitempty"`
+	Timeout      string        `json:"timeout,omitempty" description:"Tracing timeout as a duration, e.g. 10s." example:"5s"`
+
+	DisableStorage   bool `json:"disableStorage,omitempty" description:"Struct logger: omit storage."`
+	DisableStack     bool `json:"disableStack,omitempty" description:"Struct logger: omit stack."`
+	EnableMemory     bool `json:"enableMemory,omitempty" description:"Struct logger: capture memory."`
+	EnableReturnData bool `json:"enableReturnData,omitempty" description:"Struct logger: capture return data."`
+	Limit            int  `json:"limit,omitempty" minimum:"0" description:"Struct logger: maximum number of logs, 0 for no limit."`
+}
+
+// TracerConfig holds options of built-in tracers.
+type TracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall,omitempty" description:"callTracer: omit nested calls."`
+	WithLog     bool `json:"withLog,omitempty" description:"callTracer: include logs emitted by calls."`
+	DiffMode    bool `json:"diffMode,omitempty" description:"prestateTracer: return modified accounts before and after the transaction."`
+}
+
+// Trace is a transaction trace in the form of the chosen tracer, forms are told
+// apart by their required fields.
+type Trace struct {
+	// Fields are tagged for schema reflection only, JSON encoding is custom.
+	StructLogs   *StructLogTrace `json:"structLogs"`
+	Call         *CallFrame      `json:"call"`
+	Prestate     Prestate        `json:"prestate"`
+	PrestateDiff *PrestateDiff   `json:"prestateDiff"`
+}
+
+// MarshalJSON encodes whichever form is set.
+func (t Trace) MarshalJSON() ([]byte, error) {
+	switch {
+	case t.Call != nil:
+		return json.Marshal(t.Call)
+	case t.Prestate != nil:
+		return json.Marshal(t.Prestate)
+	case t.PrestateDiff != nil:
+		return json.Marshal(t.PrestateDiff)
+	default:
+		return json.Marshal(t.StructLogs)
+	}
+}
+
+// UnmarshalJSON tells the form by its distinctive fields.
+func (t *Trace) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	_, hasType := fields["type"]
+	_, hasPre := fields["pre"]
+	_, hasStructLogs := fields["structLogs"]
+
+	switch {
+	case hasStructLogs:
+		return json.Unmarshal(data, &t.StructLogs)
+	case hasType:
+		return json.Unmarshal(data, &t.Call)
+	case hasPre:
+		return json.Unmarshal(data, &t.PrestateDiff)
+	default:
+		return json.Unmarshal(data, &t.Prestate)
+	}
+}
+
+// PrepareJSONSchema documents tracer forms.
+func (*Trace) PrepareJSONSchema(s *jsonschema.Schema) error {
+	return unionSchema(s, "Struct logs when no tracer is chosen, call frame of callTracer, "+
+		"or accounts of prestateTracer.", "structLogs", "call", "prestate", "prestateDiff")
+}
+
+// TxTrace is a trace of a block transaction.
+type TxTrace struct {
+	TxHash Hash   `json:"txHash"`
+	Result *Trace `json:"result,omitempty" description:"Trace, omitted on error."`
+	Error  string `json:"error,omitempty" description:"Tracing error."`
+}
+
+// StructLogTrace is a result of the struct logger.
+type StructLogTrace struct {
+	Gas         uint64      `json:"gas" description:"Gas used."`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue" description:"Hex encoded return value without 0x prefix."`
+	StructLogs  []StructLog `json:"structLogs" required:"true"`
+}
+
+// StructLog is an executed opcode with machine state before its execution.
+type StructLog struct {
+	PC         uint64            `json:"pc"`
+	Op         string            `json:"op"`
+	Gas        uint64            `json:"gas"`
+	GasCost    uint64            `json:"gasCost"`
+	Depth      int               `json:"depth"`
+	Error      string            `json:"error,omitempty"`
+	Stack      []string          `json:"stack,omitempty" description:"Stack items, omitted with disableStack."`
+	Memory     []string          `json:"memory,omitempty" description:"32 byte memory words, captured with enableMemory."`
+	ReturnData string            `json:"returnData,omitempty" description:"Captured with enableReturnData."`
+	Storage    map[string]string `json:"storage,omitempty" description:"Storage slots accessed so far, omitted with disableStorage."`
+}
+
+// CallFrame is a call traced by callTracer with its nested calls.
+type CallFrame struct {
+	Type         string      `json:"type" required:"true" enum:"CALL,CALLCODE,DELEGATECALL,STATICCALL,CREATE,CREATE2,SELFDESTRUCT"`
+	From         Address     `json:"from"`
+	To           *Address    `json:"to,omitempty" description:"Callee, or created contract address."`
+	Value        *Quantity   `json:"value,omitempty" description:"Omitted for static and delegate calls."`
+	Gas          Quantity    `json:"gas"`
+	GasUsed      Quantity    `json:"gasUsed"`
+	Input        Data        `json:"input"`
+	Output       *Data       `json:"output,omitempty"`
+	Error        string      `json:"error,omitempty"`
+	RevertReason string      `json:"revertReason,omitempty"`
+	Calls        []CallFrame `json:"calls,omitempty" description:"Nested calls, omitted with onlyTopCall."`
+	Logs         []CallLog   `json:"logs,omitempty" description:"Logs emitted by the call, included with withLog."`
+}
+
+// CallLog is a log emitted by a traced call.
+type CallLog struct {
+	Address Address `json:"address"`
+	Topics  []Hash  `json:"topics"`
+	Data    Data    `json:"data"`
+}
+
+// Prestate maps addresses to state of accounts touched by a transaction.
+type Prestate map[Address]PrestateAccount
+
+// PrestateAccount is an account state, empty fields are omitted.
+type PrestateAccount struct {
+	Balance *Quantity     `json:"balance,omitempty"`
+	Nonce   uint64        `json:"nonce,omitempty"`
+	Code    *Data         `json:"code,omitempty"`
+	Storage map[Hash]Hash `json:"storage,omitempty"`
+}
+
+// PrestateDiff is a prestateTracer result in diff mode.
+type PrestateDiff struct {
+	Pre  Prestate `json:"pre" required:"true" description:"Modified accounts before the transaction."`
+	Post Prestate `json:"post" required:"true" description:"Modified fields of accounts after the transaction."`
+}
+
+// SyncStatus describes sync progress of the node.
+type SyncStatus struct {
+	StartingBlock Quantity `json:"startingBlock"`
+	CurrentBlock  Quantity `json:"currentBlock"`
+	HighestBlock  Quantity `json:"highestBlock"`
+}
+
+// Syncing is a sync status, or false when the node is not syncing.
+type Syncing struct {
+	// Fields are tagged for schema reflection only, JSON encoding is custom.
+	Status     *SyncStatus `json:"status"`
+	NotSyncing bool        `json:"notSyncing"`
+}
+
+// MarshalJSON encodes false for nil status.
+func (s Syncing) MarshalJSON() ([]byte, error) {
+	if s.Status == nil {
+		return []byte("false"), nil
+	}
+
+	return json.Marshal(s.Status)
+}
+
+// UnmarshalJSON decodes false or sync status.
+func (s *Syncing) UnmarshalJSON(data []byte) error {
+	var b bool
+
+	if err := json.Unmarshal(data, &b); err == nil {
+		if b {
+			return fmt.Errorf("unexpected sync status: %s", data)
+		}
+
+		s.Status = nil
+
+		return nil
+	}
+
+	return json.Unmarshal(data, &s.Status)
+}
+
+// PrepareJSONSchema documents both forms.
+func (Syncing) PrepareJSONSchema(s *jsonschema.Schema) error {
+	if err := unionSchema(s, "Sync status object, or false when not syncing.", "status", "notSyncing"); err != nil {
+		return err
+	}
+
+	if len(s.AnyOf) > 1 {
+		s.AnyOf[1].TypeObjectEns().WithEnum(false)
+	}
+
+	return nil
+}
+
+// unionSchema turns reflected object schema into anyOf schemas of given properties.
+func unionSchema(s *jsonschema.Schema, description string, names ...string) error {
+	// Properties are not yet reflected on the first call.
+	if len(s.Properties) == 0 {
+		return nil
+	}
+
+	union := jsonschema.Schema{}
+
+	for _, name := range names {
+		prop, found := s.Properties[name]
+		if !found {
+			return fmt.Errorf("missing union property %q", name)
+		}
+
+		union.AnyOf = append(union.AnyOf, prop)
+	}
+
+	union.WithDescription(description)
+	*s = union
+
+	return nil
+}
+
+// anyOf builds an inline schema that matches any of given values.
+func anyOf(values ...interface{}) jsonschema.Schema {
+	r := jsonschema.Reflector{}
+	s := jsonschema.Schema{}
+
+	reflectZeroValues(&r)
+
+	for _, v := range values {
+		vs, err := r.Reflect(v, jsonschema.InlineRefs)
+		if err != nil {
+			panic(fmt.Sprintf("failed to reflect %T: %s", v, err))
+		}
+
+		s.AnyOf = append(s.AnyOf, vs.ToSchemaOrBool())
+	}
+
+	return s
+}
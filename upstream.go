@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/swaggest/jsonrpc"
+	"github.com/swaggest/usecase"
+)
+
+// Caller invokes JSON-RPC methods.
+type Caller interface {
+	Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+}
+
+// defaultClient is used by upstreams without own client, so that a hung node fails the call.
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// Upstream is a JSON-RPC node that calls are forwarded to.
+type Upstream struct {
+	URL    string
+	Client *http.Client
+
+	lastID int64
+}
+
+// UpstreamError is a JSON-RPC error returned by the upstream node.
+type UpstreamError struct {
+	Code    jsonrpc.ErrorCode
+	Message string
+	Data    interface{}
+}
+
+// Error returns upstream error message.
+func (e UpstreamError) Error() string {
+	return e.Message
+}
+
+// Fields exposes upstream error code and data.
+func (e UpstreamError) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"code": e.Code,
+		"data": e.Data,
+	}
+}
+
+// Call invokes method on the upstream node and returns its raw result.
+func (u *Upstream) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	if len(params) == 0 {
+		params = json.RawMessage("[]")
+	}
+
+	var id interface{} = atomic.AddInt64(&u.lastID, 1)
+
+	reqBody, err := json.Marshal(jsonrpc.Request{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      &id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.URL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := u.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request failed: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream responded with status %s", resp.Status)
+	}
+
+	var rpcResp jsonrpc.Response
+
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, fmt.Errorf("failed to decode upstream response: %w", err)
+	}
+
+	if rpcResp.Error != nil {
+		return nil, UpstreamError{
+			Code:    rpcResp.Error.Code,
+			Message: rpcResp.Error.Message,
+			Data:    rpcResp.Error.Data,
+		}
+	}
+
+	if rpcResp.Result == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	return rpcResp.Result, nil
+}
+
+// forward returns an interactor that relays method call to caller.
+func forward(c Caller, method string) usecase.Interact {
+	return func(ctx context.Context, input, output interface{}) error {
+		var params json.RawMessage
+
+		if in, ok := input.(*json.RawMessage); ok {
+			params = *in
+		}
+
+		result, err := c.Call(ctx, method, params)
+		if err != nil {
+			var upstreamErr UpstreamError
+			if errors.As(err, &upstreamErr) {
+				if slot, ok := ctx.Value(upstreamErrCtxKey{}).(*upstreamErrSlot); ok {
+					slot.set(upstreamErr)
+				}
+			}
+
+			return err
+		}
+
+		out, ok := output.(*json.RawMessage)
+		if !ok {
+			return fmt.Errorf("unexpected %s output type %T", method, output)
+		}
+
+		*out = result
+
+		return nil
+	}
+}
+
+type upstreamErrCtxKey struct{}
+
+// upstreamErrSlot keeps upstream error of a request for Passthrough.
+type upstreamErrSlot struct {
+	mu  sync.Mutex
+	err *UpstreamError
+}
+
+func (s *upstreamErrSlot) set(err UpstreamError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = &err
+}
+
+func (s *upstreamErrSlot) get() *UpstreamError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Passthrough serves single JSON-RPC requests with the handler, so that errors of the
+// upstream node reach the client as is, instead of the data of handler's "operation failed"
+// error. Omitted or null params are passed as an empty array.
+//
+// Methods that Allowed returns false for are not found, whatever their params are.
+type Passthrough struct {
+	Handler http.Handler
+	Allowed func(ctx context.Context, method string) bool
+}
+
+// ServeHTTP defaults params and replaces handler error with upstream error.
+func (p Passthrough) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, jsonrpc.CodeParseError, fmt.Errorf("failed to read request body: %w", err))
+
+		return
+	}
+
+	var call jsonrpc.Request
+
+	// Denied methods are not found before their params are validated.
+	if p.Allowed != nil && json.Unmarshal(body, &call) == nil && call.JSONRPC == "2.0" &&
+		!p.Allowed(r.Context(), call.Method) {
+		deny(w, call)
+
+		return
+	}
+
+	var req map[string]json.RawMessage
+
+	// Invalid requests and batches are left for the handler to report.
+	if json.Unmarshal(body, &req) == nil {
+		if params := bytes.TrimSpace(req["params"]); len(params) == 0 || string(params) == "null" {
+			req["params"] = json.RawMessage("[]")
+
+			if b, err := json.Marshal(req); err == nil {
+				body = b
+			}
+		}
+	}
+
+	slot := &upstreamErrSlot{}
+
+	r = r.WithContext(context.WithValue(r.Context(), upstreamErrCtxKey{}, slot))
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	resp := &bufferedResponse{header: http.Header{}}
+	p.Handler.ServeHTTP(resp, r)
+
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+
+	out := resp.body.Bytes()
+
+	if upstreamErr := slot.get(); upstreamErr != nil {
+		out = replaceError(out, upstreamErr)
+	}
+
+	_, _ = w.Write(out)
+}
+
+// deny responds to call with method not found error, notifications get no response.
+func deny(w http.ResponseWriter, call jsonrpc.Request) {
+	w.Header().Set("Content-Type", "application/json; charset: utf-8")
+
+	if call.ID == nil {
+		return
+	}
+
+	data, err := json.Marshal(jsonrpc.Response{
+		JSONRPC: "2.0",
+		ID:      call.ID,
+		Error:   &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: methodNotFound(call.Method).Error()},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	_, _ = w.Write(data)
+}
+
+// replaceError puts upstream error into JSON-RPC response, response is returned as is if it has no error.
+func replaceError(resp []byte, upstreamErr *UpstreamError) []byte {
+	var r struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   json.RawMessage `json:"error"`
+	}
+
+	if err := json.Unmarshal(resp, &r); err != nil || r.Error == nil {
+		return resp
+	}
+
+	out, err := json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Error   jsonrpc.Error   `json:"error"`
+		ID      json.RawMessage `json:"id"`
+	}{
+		JSONRPC: r.JSONRPC,
+		Error:   jsonrpc.Error{Code: upstreamErr.Code, Message: upstreamErr.Message, Data: upstreamErr.Data},
+		ID:      r.ID,
+	})
+	if err != nil {
+		return resp
+	}
+
+	return out
+}
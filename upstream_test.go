@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swaggest/jsonrpc"
+)
+
+// callerFunc calls a function for every method.
+type callerFunc func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+
+func (f callerFunc) Call(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	return f(ctx, method, params)
+}
+
+// newTestRPC serves catalog methods with caller the way /rpc does.
+func newTestRPC(t *testing.T, caller Caller) BatchHandler {
+	t.Helper()
+
+	catalog, err := LoadCatalog("catalog.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := newHandler(catalog, caller, &jsonrpc.JSONSchemaValidator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return BatchHandler{Handler: Passthrough{Handler: h}, Concurrency: 4}
+}
+
+// postRPC sends body to handler and returns response body.
+func postRPC(t *testing.T, h http.Handler, body string) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+
+	return strings.TrimSpace(rec.Body.String())
+}
+
+func TestPassthrough(t *testing.T) {
+	var gotParams string
+
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		switch req.Method {
+		case "eth_call":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) +
+				`,"error":{"code":3,"message":"execution reverted","data":"0x08c379a0"}}`))
+		case "net_peerCount":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			gotParams = string(req.Params)
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"338"}`))
+		}
+	}))
+	defer node.Close()
+
+	h := newTestRPC(t, &Upstream{URL: node.URL, Client: &http.Client{Timeout: 50 * time.Millisecond}})
+
+	call := `{"from":"0x0000000000000000000000000000000000000000","to":"0x0000000000000000000000000000000000000001"}`
+
+	for _, tc := range []struct {
+		name, body, want string
+	}{
+		{
+			name: "upstream error",
+			body: `{"jsonrpc":"2.0","method":"eth_call","params":[` + call + `,"latest"],"id":7}`,
+			want: `{"jsonrpc":"2.0","error":{"code":3,"message":"execution reverted","data":"0x08c379a0"},"id":7}`,
+		},
+		{
+			name: "upstream error in batch",
+			body: `[{"jsonrpc":"2.0","method":"eth_call","params":[` + call + `],"id":"a"},` +
+				`{"jsonrpc":"2.0","method":"net_version","params":[],"id":"b"}]`,
+			want: `[{"jsonrpc":"2.0","error":{"code":3,"message":"execution reverted","data":"0x08c379a0"},"id":"a"},` +
+				`{"jsonrpc":"2.0","result":"338","id":"b"}]`,
+		},
+		{
+			name: "omitted params",
+			body: `{"jsonrpc":"2.0","method":"net_version","id":1}`,
+			want: `{"jsonrpc":"2.0","result":"338","id":1}`,
+		},
+		{
+			name: "null params",
+			body: `{"jsonrpc":"2.0","method":"net_version","params":null,"id":1}`,
+			want: `{"jsonrpc":"2.0","result":"338","id":1}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := postRPC(t, h, tc.body); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+
+	if gotParams != "[]" {
+		t.Errorf("upstream got params %s, want []", gotParams)
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		got := postRPC(t, h, `{"jsonrpc":"2.0","method":"net_peerCount","params":[],"id":1}`)
+
+		var resp struct {
+			Error struct {
+				Code int    `json:"code"`
+				Data string `json:"data"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal([]byte(got), &resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Error.Code != -32603 || !strings.Contains(resp.Error.Data, "Timeout") {
+			t.Errorf("unexpected response %s", got)
+		}
+	})
+}
+
+func TestReplaceError(t *testing.T) {
+	resp := []byte(`{"jsonrpc":"2.0","result":"0x1","id":1}`)
+
+	if got := replaceError(resp, &UpstreamError{Code: 3}); !bytes.Equal(got, resp) {
+		t.Errorf("response without error changed: %s", got)
+	}
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/swaggest/jsonrpc"
+)
+
+// Subscriber streams notifications of eth_subscribe subscriptions.
+type Subscriber interface {
+	// Subscribe starts subscription with eth_subscribe params, notify receives
+	// notification results until ctx is done. Closed is called if subscription
+	// ends before ctx is done, e.g. when upstream connection is lost.
+	Subscribe(
+		ctx context.Context, params json.RawMessage, notify func(result json.RawMessage), closed func(err error),
+	) error
+}
+
+// Limits of a WebSocket connection.
+const (
+	// wsMaxSubscriptions limits number of active subscriptions of a connection.
+	wsMaxSubscriptions = 64
+	// wsMaxPending limits number of notifications held until subscription id is sent,
+	// later ones are dropped.
+	wsMaxPending = 256
+	// wsMaxMessageSize limits size of a client message, the same as the limit of geth.
+	wsMaxMessageSize = 15 * 1024 * 1024
+	// wsPingPeriod is the default interval of pings.
+	wsPingPeriod = 30 * time.Second
+	// wsWriteWait limits time of writing a ping.
+	wsWriteWait = 10 * time.Second
+)
+
+// codeLimitExceeded is the EIP-1474 error code of requests that exceed a limit.
+const codeLimitExceeded jsonrpc.ErrorCode = -32005
+
+// WSUpstream is a WebSocket endpoint of the upstream node.
+type WSUpstream struct {
+	URL    string
+	Dialer *websocket.Dialer
+}
+
+// Subscribe opens a connection to the upstream node for the subscription, it is closed when ctx is done.
+func (u *WSUpstream) Subscribe(
+	ctx context.Context, params json.RawMessage, notify func(json.RawMessage), closed func(error),
+) error {
+	dialer := u.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	conn, _, err := dialer.DialContext(ctx, u.URL, nil)
+	if err != nil {
+		return fmt.Errorf("upstream connection failed: %w", err)
+	}
+
+	var id interface{} = 1
+
+	if err := conn.WriteJSON(jsonrpc.Request{JSONRPC: "2.0", Method: "eth_subscribe", Params: params, ID: &id}); err != nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("upstream request failed: %w", err)
+	}
+
+	var resp jsonrpc.Response
+
+	_ = conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+	if err := conn.ReadJSON(&resp); err != nil {
+		_ = conn.Close()
+
+		return fmt.Errorf("failed to decode upstream response: %w", err)
+	}
+
+	if resp.Error != nil {
+		_ = conn.Close()
+
+		return UpstreamError{Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	go func() {
+		for {
+			var n subscriptionNotification
+
+			if err := conn.ReadJSON(&n); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("upstream subscription %s closed: %v", u.URL, err)
+					closed(err)
+				}
+
+				return
+			}
+
+			if n.Method == "eth_subscription" {
+				notify(n.Params.Result)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// subscriptionNotification is a message with subscription result, or error if subscription is closed by the server.
+type subscriptionNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result,omitempty"`
+		Error        *jsonrpc.Error  `json:"error,omitempty"`
+	} `json:"params"`
+}
+
+// WSHandler serves JSON-RPC over WebSocket.
+//
+// Subscriptions are served by the backend of the network chosen for the connection,
+// other calls are passed to the HTTP handler.
+type WSHandler struct {
+	Networks  *Networks
+	Handler   http.Handler
+	Validator jsonrpc.Validator
+	Upgrader  websocket.Upgrader
+
+	// PingPeriod is an interval of pings, 30s if zero. Connection is closed if the client
+	// neither sends messages nor answers pings for two periods.
+	PingPeriod time.Duration
+}
+
+// ServeHTTP upgrades connection and serves its messages until it is closed.
+func (h *WSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrader responds with error.
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	c := &wsConn{
+		conn:          conn,
+		handler:       h,
+		request:       r.WithContext(ctx),
+		network:       h.Networks.NetworkFromContext(ctx),
+		subscriptions: map[string]*wsSubscription{},
+	}
+
+	defer func() {
+		cancel()
+		_ = conn.Close()
+	}()
+
+	pingPeriod := h.PingPeriod
+	if pingPeriod <= 0 {
+		pingPeriod = wsPingPeriod
+	}
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * pingPeriod))
+	})
+
+	go c.ping(ctx, pingPeriod)
+
+	for {
+		// Deadline is extended after a message is served, as pongs are not read while it is served.
+		if err := conn.SetReadDeadline(time.Now().Add(2 * pingPeriod)); err != nil {
+			return
+		}
+
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if err := c.serve(ctx, msg); err != nil {
+			return
+		}
+	}
+}
+
+// ping sends pings to the client until ctx is done.
+func (c *wsConn) ping(ctx context.Context, period time.Duration) {
+	t := time.NewTicker(period)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type wsConn struct {
+	conn    *websocket.Conn
+	handler *WSHandler
+	request *http.Request
+	network *Network
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	subscriptions map[string]*wsSubscription
+}
+
+// wsSubscription holds notifications until subscription id is sent to the client.
+type wsSubscription struct {
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	ready   bool
+	pending [][]byte
+}
+
+// send writes notification to the client or holds it if subscription id is not yet sent.
+func (s *wsSubscription) send(c *wsConn, msg []byte) error {
+	s.mu.Lock()
+
+	if !s.ready {
+		if len(s.pending) < wsMaxPending {
+			s.pending = append(s.pending, msg)
+		}
+
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	s.mu.Unlock()
+
+	return c.write(msg)
+}
+
+// flush writes held notifications after subscription id, write lock must be held.
+func (s *wsSubscription) flush(c *wsConn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ready = true
+
+	for _, msg := range s.pending {
+		if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			return err
+		}
+	}
+
+	s.pending = nil
+
+	return nil
+}
+
+func (c *wsConn) write(msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteMessage(websocket.TextMessage, msg)
+}
+
+// serve handles a message and writes response.
+func (c *wsConn) serve(ctx context.Context, msg []byte) error {
+	var req jsonrpc.Request
+
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '{' && json.Unmarshal(msg, &req) == nil && req.JSONRPC == "2.0" {
+		switch req.Method {
+		case "eth_subscribe":
+			sub, id, err := c.subscribe(ctx, req.Params)
+
+			c.writeMu.Lock()
+			defer c.writeMu.Unlock()
+
+			if err := c.respond(req, id, err); err != nil || sub == nil {
+				return err
+			}
+
+			return sub.flush(c)
+		case "eth_unsubscribe":
+			ok, err := c.unsubscribe(ctx, req.Params)
+
+			c.writeMu.Lock()
+			defer c.writeMu.Unlock()
+
+			return c.respond(req, ok, err)
+		}
+	}
+
+	r := c.request.Clone(ctx)
+	r.Method = http.MethodPost
+	r.Body = ioutil.NopCloser(bytes.NewReader(msg))
+	r.ContentLength = int64(len(msg))
+
+	resp := &bufferedResponse{header: http.Header{}}
+	c.handler.Handler.ServeHTTP(resp, r)
+
+	if out := bytes.TrimSpace(resp.body.Bytes()); len(out) > 0 {
+		return c.write(out)
+	}
+
+	return nil
+}
+
+// respond writes result or error of subscription method, write lock must be held.
+func (c *wsConn) respond(req jsonrpc.Request, result interface{}, err error) error {
+	if req.ID == nil {
+		return nil
+	}
+
+	resp := jsonrpc.Response{JSONRPC: "2.0", ID: req.ID}
+
+	var (
+		validationErr jsonrpc.ValidationErrors
+		upstreamErr   UpstreamError
+	)
+
+	if err == nil {
+		resp.Result, err = json.Marshal(result)
+	}
+
+	switch {
+	case err == nil:
+	case errors.As(err, &upstreamErr):
+		resp.Error = &jsonrpc.Error{Code: upstreamErr.Code, Message: upstreamErr.Message, Data: upstreamErr.Data}
+	case errors.As(err, &validationErr):
+		resp.Error = errorWithFields("invalid parameters", jsonrpc.CodeInvalidParams, err)
+	default:
+		resp.Error = errorWithFields("operation failed", jsonrpc.CodeInternalError, err)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// errorWithFields builds error the way jsonrpc.Handler does, with structured data.
+func errorWithFields(msg string, code jsonrpc.ErrorCode, err error) *jsonrpc.Error {
+	e := &jsonrpc.Error{Code: code, Message: msg, Data: err.Error()}
+
+	var withFields jsonrpc.ErrWithFields
+	if errors.As(err, &withFields) {
+		e.Data = map[string]interface{}{
+			"error":   withFields.Error(),
+			"context": withFields.Fields(),
+		}
+	}
+
+	return e
+}
+
+// subscribe starts subscription, its notifications are held until the returned subscription is flushed.
+func (c *wsConn) subscribe(ctx context.Context, params json.RawMessage) (*wsSubscription, string, error) {
+	if !c.network.Enabled("eth_subscribe") || !keyAllows(ctx, "eth_subscribe") {
+		return nil, "", methodNotFound("eth_subscribe")
+	}
+
+	if c.handler.Validator != nil {
+		if err := c.handler.Validator.ValidateParams("eth_subscribe", params); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if c.network.subscriber == nil {
+		return nil, "", UpstreamError{
+			Code:    jsonrpc.CodeMethodNotFound,
+			Message: "subscriptions are not available on network " + c.network.Name,
+		}
+	}
+
+	id, err := subscriptionID()
+	if err != nil {
+		return nil, "", err
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := &wsSubscription{cancel: cancel}
+
+	// Subscription is registered before it starts, so that it can be removed if upstream closes it right away.
+	c.mu.Lock()
+
+	if len(c.subscriptions) >= wsMaxSubscriptions {
+		c.mu.Unlock()
+		cancel()
+
+		return nil, "", UpstreamError{
+			Code:    codeLimitExceeded,
+			Message: fmt.Sprintf("connection has %d subscriptions, unsubscribe to start a new one", wsMaxSubscriptions),
+		}
+	}
+
+	c.subscriptions[id] = sub
+	c.mu.Unlock()
+
+	notify := func(result json.RawMessage) {
+		var n subscriptionNotification
+
+		n.JSONRPC = "2.0"
+		n.Method = "eth_subscription"
+		n.Params.Subscription = id
+		n.Params.Result = result
+
+		data, err := json.Marshal(n)
+		if err == nil {
+			err = sub.send(c, data)
+		}
+
+		if err != nil {
+			c.remove(id)
+		}
+	}
+
+	// Client is told that subscription is closed with a notification that has error instead of result.
+	closed := func(err error) {
+		if !c.remove(id) {
+			return
+		}
+
+		var n subscriptionNotification
+
+		n.JSONRPC = "2.0"
+		n.Method = "eth_subscription"
+		n.Params.Subscription = id
+		n.Params.Error = &jsonrpc.Error{Code: -32000, Message: "subscription closed: " + err.Error()}
+
+		if data, err := json.Marshal(n); err == nil {
+			_ = sub.send(c, data)
+		}
+	}
+
+	if err := c.network.subscriber.Subscribe(subCtx, params, notify, closed); err != nil {
+		c.remove(id)
+
+		return nil, "", err
+	}
+
+	return sub, id, nil
+}
+
+// remove cancels subscription and forgets it, it returns false if there is no such subscription.
+func (c *wsConn) remove(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sub, ok := c.subscriptions[id]
+	if ok {
+		sub.cancel()
+		delete(c.subscriptions, id)
+	}
+
+	return ok
+}
+
+func (c *wsConn) unsubscribe(ctx context.Context, params json.RawMessage) (bool, error) {
+	if !c.network.Enabled("eth_unsubscribe") || !keyAllows(ctx, "eth_unsubscribe") {
+		return false, methodNotFound("eth_unsubscribe")
+	}
+
+	if c.handler.Validator != nil {
+		if err := c.handler.Validator.ValidateParams("eth_unsubscribe", params); err != nil {
+			return false, err
+		}
+	}
+
+	var ids []string
+
+	if err := json.Unmarshal(params, &ids); err != nil || len(ids) != 1 {
+		return false, invalidParams("subscription id expected")
+	}
+
+	return c.remove(ids[0]), nil
+}
+
+func subscriptionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return "0x" + hex.EncodeToString(id), nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testSubscriber notifies right away, before subscription id is sent, and closes
+// subscriptions with "drop" param as if upstream connection is lost.
+type testSubscriber struct{}
+
+func (testSubscriber) Subscribe(
+	ctx context.Context, params json.RawMessage, notify func(json.RawMessage), closed func(error),
+) error {
+	var p []string
+
+	_ = json.Unmarshal(params, &p)
+
+	notify(json.RawMessage(`"0x1"`))
+	notify(json.RawMessage(`"0x2"`))
+
+	if p[0] == "drop" {
+		go closed(errors.New("connection reset"))
+	}
+
+	return nil
+}
+
+func dialTestWS(t *testing.T) *websocket.Conn {
+	t.Helper()
+
+	return dialTestWSHandler(t, &WSHandler{})
+}
+
+// dialTestWSHandler connects to the handler serving test network.
+func dialTestWSHandler(t *testing.T, h *WSHandler) *websocket.Conn {
+	t.Helper()
+
+	h.Networks = &Networks{List: []*Network{{Name: "test", subscriber: testSubscriber{}}}}
+	h.Handler = http.NotFoundHandler()
+	srv := httptest.NewServer(h)
+
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	t.Helper()
+
+	var msg map[string]interface{}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+
+	return msg
+}
+
+func TestWSSubscribe(t *testing.T) {
+	conn := dialTestWS(t)
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "eth_subscribe", "params": []string{"newHeads"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := readWS(t, conn)
+
+	id, _ := resp["result"].(string)
+	if id == "" {
+		t.Fatalf("subscription id expected first, got %v", resp)
+	}
+
+	for _, want := range []string{"0x1", "0x2"} {
+		n := readWS(t, conn)
+		params, _ := n["params"].(map[string]interface{})
+
+		if n["method"] != "eth_subscription" || params["subscription"] != id || params["result"] != want {
+			t.Errorf("unexpected notification %v", n)
+		}
+	}
+}
+
+func TestWSSubscriptionClosed(t *testing.T) {
+	conn := dialTestWS(t)
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "eth_subscribe", "params": []string{"drop"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := readWS(t, conn)["result"].(string)
+
+	var closed bool
+
+	for i := 0; i < 3 && !closed; i++ {
+		params, _ := readWS(t, conn)["params"].(map[string]interface{})
+		_, closed = params["error"]
+
+		if params["subscription"] != id {
+			t.Errorf("unexpected subscription %v", params["subscription"])
+		}
+	}
+
+	if !closed {
+		t.Fatal("closed subscription is not reported")
+	}
+
+	// Closed subscription is removed.
+	if err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 2, "method": "eth_unsubscribe", "params": []string{id},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := readWS(t, conn); resp["result"] != false {
+		t.Errorf("unexpected unsubscribe response %v", resp)
+	}
+}
+
+func TestWSSubscriptionLimit(t *testing.T) {
+	conn := dialTestWS(t)
+
+	for i := 0; i <= wsMaxSubscriptions; i++ {
+		if err := conn.WriteJSON(map[string]interface{}{
+			"jsonrpc": "2.0", "id": i, "method": "eth_subscribe", "params": []string{"newHeads"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Subscription id and two notifications.
+		resp := readWS(t, conn)
+
+		if i == wsMaxSubscriptions {
+			e, _ := resp["error"].(map[string]interface{})
+			if e["code"] != float64(codeLimitExceeded) {
+				t.Fatalf("limit error expected, got %v", resp)
+			}
+
+			return
+		}
+
+		readWS(t, conn)
+		readWS(t, conn)
+	}
+}
+
+func TestWSKeepalive(t *testing.T) {
+	h := &WSHandler{PingPeriod: 50 * time.Millisecond}
+
+	// Client that answers pings stays connected.
+	conn := dialTestWSHandler(t, h)
+	pings := make(chan struct{}, 100)
+	messages := make(chan []byte, 10)
+
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				close(messages)
+
+				return
+			}
+
+			messages <- msg
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "eth_subscribe", "params": []string{"newHeads"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg, ok := <-messages:
+		if !ok || !strings.Contains(string(msg), `"result"`) {
+			t.Fatalf("subscription expected, got %s", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no response")
+	}
+
+	if len(pings) < 2 {
+		t.Errorf("expected pings, got %d", len(pings))
+	}
+
+	// Client that does not answer pings is disconnected.
+	idle := dialTestWSHandler(t, h)
+
+	time.Sleep(300 * time.Millisecond)
+
+	_ = idle.SetReadDeadline(time.Now().Add(time.Second))
+
+	for {
+		if _, _, err := idle.ReadMessage(); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Fatal("connection is not closed")
+			}
+
+			break
+		}
+	}
+}
+
+func TestWSReadLimit(t *testing.T) {
+	conn := dialTestWS(t)
+
+	_ = conn.WriteMessage(websocket.TextMessage, make([]byte, wsMaxMessageSize+1))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("message too big close expected, got %v", err)
+	}
+}